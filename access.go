@@ -0,0 +1,71 @@
+package doozer
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+)
+
+// An AccessProvider supplies the secret a Conn presents to the
+// server. Token is called each time the Conn authenticates, so a
+// provider may return a different token as secrets are rotated.
+type AccessProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is an AccessProvider that always returns itself.
+type StaticToken string
+
+func (s StaticToken) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// TokenFile is an AccessProvider that reads the token from the
+// named file each time it is asked, ignoring surrounding space.
+type TokenFile string
+
+func (f TokenFile) Token(ctx context.Context) (string, error) {
+	b, err := ioutil.ReadFile(string(f))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// AccessWith authenticates using a token from p and remembers p,
+// so that Reconnect authenticates the same way.
+func (c *Conn) AccessWith(ctx context.Context, p AccessProvider) error {
+	c.mu.Lock()
+	l := c.l
+	c.mu.Unlock()
+
+	err := authenticate(ctx, l, p)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.access = p
+	c.mu.Unlock()
+	return nil
+}
+
+// authenticate presents a token from p on l. If the server rejects
+// the token, p is asked once more, in case the token was stale.
+func authenticate(ctx context.Context, l *link, p AccessProvider) error {
+	token, err := p.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = l.call(ctx, accessTxn(token))
+	if _, ok := err.(*Error); !ok {
+		return err
+	}
+
+	fresh, ferr := p.Token(ctx)
+	if ferr != nil || fresh == token {
+		return err
+	}
+	return l.call(ctx, accessTxn(fresh))
+}
@@ -0,0 +1,197 @@
+package doozer
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadName = errors.New("bad name")
+	ErrBadAddr = errors.New("bad address")
+)
+
+// A Member is an entry for a server in a cluster's namespace,
+// /ctl/ns/<cluster>/<id>, whose body is the server's address.
+type Member struct {
+	ID   string
+	Addr string
+	Rev  int64
+}
+
+// A CalSlot is an entry in the calendar, /ctl/cal/<n>, naming the
+// node that holds slot n. An empty Node means the slot is open.
+type CalSlot struct {
+	Slot int
+	Node string
+	Rev  int64
+}
+
+// A Node describes a server in the cluster, from the files it keeps
+// under /ctl/node/<id>. Info holds the body of each of them, such as
+// "addr" and "hostname", by path relative to that directory.
+type Node struct {
+	ID      string
+	Addr    string
+	Version string
+	Info    map[string]string
+}
+
+// validName reports whether s can be used as a single path component.
+func validName(s string) bool {
+	if s == "" || s == "." || s == ".." {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case r == '.', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Members returns the members registered in the namespace of the
+// named cluster, at revision rev, in order of ID.
+func Members(c Client, cluster string, rev int64) ([]Member, error) {
+	if !validName(cluster) {
+		return nil, ErrBadName
+	}
+
+	dir := "/ctl/ns/" + cluster
+	ids, err := c.Getdir(dir, rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := make([]Member, len(ids))
+	for i, id := range ids {
+		body, frev, err := c.Get(dir+"/"+id, &rev)
+		if err != nil {
+			return nil, err
+		}
+		ms[i] = Member{id, string(body), frev}
+	}
+	return ms, nil
+}
+
+// AddMember registers addr as member id of the named cluster.
+// It fails with ErrOldRev if the member is already registered.
+func AddMember(c Client, cluster, id, addr string) (rev int64, err error) {
+	if !validName(cluster) || !validName(id) {
+		return 0, ErrBadName
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return 0, ErrBadAddr
+	}
+	return c.Set("/ctl/ns/"+cluster+"/"+id, missing, []byte(addr))
+}
+
+// RemoveMember removes member id of the named cluster from the
+// namespace, if it hasn't been modified since rev.
+func RemoveMember(c Client, cluster, id string, rev int64) error {
+	if !validName(cluster) || !validName(id) {
+		return ErrBadName
+	}
+	return c.Del("/ctl/ns/"+cluster+"/"+id, rev)
+}
+
+// CalSlots returns the calendar at revision rev, in order of slot.
+func CalSlots(c Client, rev int64) ([]CalSlot, error) {
+	names, err := c.Getdir("/ctl/cal", rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []CalSlot
+	for _, name := range names {
+		n, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		body, frev, err := c.Get("/ctl/cal/"+name, &rev)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, CalSlot{n, strings.TrimSpace(string(body)), frev})
+	}
+	sort.Sort(bySlot(slots))
+	return slots, nil
+}
+
+// NominateSlot adds the open slot n to the calendar, to be taken by
+// a node waiting to join the consensus. It fails with ErrOldRev if
+// the slot already exists.
+func NominateSlot(c Client, n int) (rev int64, err error) {
+	if n < 0 {
+		return 0, ErrBadName
+	}
+	return c.Set("/ctl/cal/"+strconv.Itoa(n), missing, nil)
+}
+
+// Demote removes from the calendar the slot held by node, if the
+// slot hasn't been modified since rev, so the node stops taking part
+// in the consensus. It returns the slot removed, and fails with
+// ErrNoEnt if the node holds no slot as of rev.
+func Demote(c Client, node string, rev int64) (slot int, err error) {
+	slots, err := CalSlots(c, rev)
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range slots {
+		if s.Node == node {
+			return s.Slot, c.Del("/ctl/cal/"+strconv.Itoa(s.Slot), s.Rev)
+		}
+	}
+	return 0, &Error{ErrNoEnt, node}
+}
+
+// Nodes returns the nodes in the cluster, at revision rev, in order
+// of ID.
+func Nodes(c Client, rev int64) ([]Node, error) {
+	ids, err := c.Getdir("/ctl/node", rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]Node, 0, len(ids))
+	for _, id := range ids {
+		n, err := NodeInfo(c, id, rev)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, *n)
+	}
+	return nodes, nil
+}
+
+// NodeInfo returns the node with the given ID, at revision rev.
+func NodeInfo(c Client, id string, rev int64) (*Node, error) {
+	if !validName(id) {
+		return nil, ErrBadName
+	}
+	dir := "/ctl/node/" + id
+	evs, err := c.Walk(dir+"/**", rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	if len(evs) == 0 {
+		return nil, &Error{ErrNoEnt, dir}
+	}
+	n := &Node{ID: id, Info: make(map[string]string, len(evs))}
+	for _, ev := range evs {
+		n.Info[strings.TrimPrefix(ev.Path, dir+"/")] = strings.TrimSpace(string(ev.Body))
+	}
+	n.Addr = n.Info["addr"]
+	n.Version = n.Info["version"]
+	return n, nil
+}
+
+type bySlot []CalSlot
+
+func (a bySlot) Len() int           { return len(a) }
+func (a bySlot) Less(i, j int) bool { return a[i].Slot < a[j].Slot }
+func (a bySlot) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -0,0 +1,132 @@
+package doozer
+
+import (
+	"context"
+)
+
+// readyName is the entry a DoubleBarrier adds to its directory once
+// every participant has entered.
+const readyName = "ready"
+
+// A Barrier holds up each of a number of participants until all of
+// them have arrived. Each registers by adding an entry to the
+// Barrier's directory.
+type Barrier struct {
+	c    Client
+	dir  string
+	n    int
+	id   string
+	name string
+}
+
+// NewBarrier returns a Barrier for n participants, kept in dir.
+func NewBarrier(c Client, dir string, n int) *Barrier {
+	id := newID()
+	return &Barrier{c: c, dir: dir, n: n, id: id, name: dir + "/" + id}
+}
+
+// Wait registers the participant and waits until n have registered.
+// It gives up when ctx is done. The entries are left in place; the
+// directory may be removed with DelTree once the Barrier is done.
+func (b *Barrier) Wait(ctx context.Context) error {
+	_, err := b.c.Set(b.name, clobber, nil)
+	if err != nil {
+		return err
+	}
+	return waitChildren(ctx, b.c, b.dir, func(names []string) bool {
+		return len(names) >= b.n
+	})
+}
+
+// A DoubleBarrier holds up each of a number of participants until
+// all of them have entered, and again until all of them have left,
+// so they start and finish a stage of work together.
+type DoubleBarrier struct {
+	Barrier
+}
+
+// NewDoubleBarrier returns a DoubleBarrier for n participants, kept
+// in dir.
+func NewDoubleBarrier(c Client, dir string, n int) *DoubleBarrier {
+	return &DoubleBarrier{*NewBarrier(c, dir, n)}
+}
+
+// Enter registers the participant and waits until n have entered.
+// It gives up when ctx is done.
+func (b *DoubleBarrier) Enter(ctx context.Context) error {
+	_, err := b.c.Set(b.name, clobber, nil)
+	if err != nil {
+		return err
+	}
+	err = waitChildren(ctx, b.c, b.dir, func(names []string) bool {
+		n := 0
+		for _, name := range names {
+			if name == readyName {
+				// Every participant entered; some have since left.
+				return true
+			}
+			n++
+		}
+		return n >= b.n
+	})
+	if err != nil {
+		return err
+	}
+
+	// Mark the barrier ready, for participants that see it only
+	// after others have begun to leave.
+	_, err = Create(b.c, b.dir+"/"+readyName, nil)
+	if e, ok := err.(*Error); ok && e.Err == ErrExists {
+		err = nil
+	}
+	return err
+}
+
+// Leave removes the participant and waits until all have left.
+// It gives up when ctx is done.
+func (b *DoubleBarrier) Leave(ctx context.Context) error {
+	err := b.c.Del(b.name, clobber)
+	if err != nil {
+		return err
+	}
+	err = waitChildren(ctx, b.c, b.dir, func(names []string) bool {
+		return len(names) == 0 || len(names) == 1 && names[0] == readyName
+	})
+	if err != nil {
+		return err
+	}
+
+	err = b.c.Del(b.dir+"/"+readyName, clobber)
+	if e, ok := err.(*Error); ok && e.Err == ErrNoEnt {
+		err = nil
+	}
+	return err
+}
+
+// waitChildren waits until the entries in dir satisfy ok.
+func waitChildren(ctx context.Context, c Client, dir string, ok func(names []string) bool) error {
+	for {
+		rev, err := c.Rev()
+		if err != nil {
+			return err
+		}
+		names, err := c.Getdir(dir, rev, 0, -1)
+		if e, isErr := err.(*Error); isErr && e.Err == ErrNoEnt {
+			names, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+		if ok(names) {
+			return nil
+		}
+
+		_, err = waitContext(ctx, c, dir+"/*", rev+1)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package doozer_test
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	srv := doozertest.NewServer()
+	const n = 3
+
+	done := make(chan error, n)
+	for i := 0; i < n-1; i++ {
+		b := doozer.NewBarrier(srv.Client(), "/barrier", n)
+		go func() { done <- b.Wait(context.Background()) }()
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v before every participant arrived", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b := doozer.NewBarrier(srv.Client(), "/barrier", n)
+	go func() { done <- b.Wait(context.Background()) }()
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Wait did not return after every participant arrived")
+		}
+	}
+}
+
+func TestBarrierCancel(t *testing.T) {
+	srv := doozertest.NewServer()
+	b := doozer.NewBarrier(srv.Client(), "/barrier", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait alone: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestDoubleBarrier(t *testing.T) {
+	srv := doozertest.NewServer()
+	const n = 2
+
+	var bs []*doozer.DoubleBarrier
+	for i := 0; i < n; i++ {
+		bs = append(bs, doozer.NewDoubleBarrier(srv.Client(), "/barrier", n))
+	}
+
+	step := func(f func(b *doozer.DoubleBarrier) error) {
+		done := make(chan error, n)
+		for _, b := range bs {
+			go func(b *doozer.DoubleBarrier) { done <- f(b) }(b)
+		}
+		for range bs {
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("participants held up")
+			}
+		}
+	}
+	step(func(b *doozer.DoubleBarrier) error { return b.Enter(context.Background()) })
+	step(func(b *doozer.DoubleBarrier) error { return b.Leave(context.Background()) })
+
+	rev := srv.Rev()
+	if names, err := srv.Client().Getdir("/barrier", rev, 0, -1); err == nil {
+		t.Fatalf("entries left after every participant left: %v", names)
+	}
+}
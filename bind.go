@@ -0,0 +1,221 @@
+package doozer
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrBadTarget = errors.New("target must be a pointer to a struct")
+	ErrBadField  = errors.New("field type cannot be bound")
+)
+
+// A Binder keeps a struct filled in from the files under a prefix.
+//
+// Each field tagged `doozer:"name"` is read from the file
+// prefix/name, whose body is parsed according to the field's type:
+// a string or []byte is taken as is; a bool, integer, or float is
+// parsed with package strconv; and a time.Duration is parsed with
+// time.ParseDuration. A field of struct type is itself filled in
+// from the files under prefix/name. A field whose file is missing
+// keeps the value it had in the target passed to Bind.
+type Binder struct {
+	m      *Mirror
+	prefix string
+	defs   reflect.Value // the target as first passed in
+	val    atomic.Value  // pointer to the latest struct
+
+	mu    sync.Mutex
+	funcs []func(v interface{})
+	err   error
+}
+
+// Bind fills in target, a pointer to a struct, from the files under
+// prefix, and keeps following them. Later values are not written to
+// target, which other goroutines may be reading; use Load instead.
+func Bind(c *Conn, prefix string, target interface{}) (*Binder, error) {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, ErrBadTarget
+	}
+	if err := checkStruct(v.Elem().Type()); err != nil {
+		return nil, err
+	}
+
+	m, err := NewMirror(c, treeGlob(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Binder{m: m, prefix: prefix, defs: reflect.New(v.Elem().Type())}
+	b.defs.Elem().Set(v.Elem())
+	files, _ := m.Snapshot()
+	next, err := b.decode(files)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	v.Elem().Set(next.Elem())
+	b.val.Store(next.Interface())
+
+	m.OnChange(b.update)
+	return b, nil
+}
+
+// Load returns a pointer to a struct of the target's type holding
+// the latest values. The struct must not be modified.
+func (b *Binder) Load() interface{} {
+	return b.val.Load()
+}
+
+// OnChange arranges for f to be called with the result of Load after
+// each change to the values.
+func (b *Binder) OnChange(f func(v interface{})) {
+	b.mu.Lock()
+	b.funcs = append(b.funcs, f)
+	b.mu.Unlock()
+}
+
+// Err returns the error from the last change that could not be
+// applied, such as a body that could not be parsed, if the values
+// have not changed successfully since. Such a change is ignored,
+// leaving the values as they were.
+func (b *Binder) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Close stops following the files.
+func (b *Binder) Close() error {
+	return b.m.Close()
+}
+
+func (b *Binder) update(Event) {
+	files, _ := b.m.Snapshot()
+	next, err := b.decode(files)
+
+	b.mu.Lock()
+	b.err = err
+	funcs := b.funcs
+	b.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	b.val.Store(next.Interface())
+	for _, f := range funcs {
+		f(next.Interface())
+	}
+}
+
+// decode returns a pointer to a copy of the defaults with the files
+// filled in.
+func (b *Binder) decode(files map[string][]byte) (reflect.Value, error) {
+	v := reflect.New(b.defs.Elem().Type())
+	v.Elem().Set(b.defs.Elem())
+	err := decodeStruct(v.Elem(), b.prefix, files)
+	return v, err
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// checkStruct reports a tagged field of t, or of a struct within it,
+// whose type cannot be bound.
+func checkStruct(t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("doozer")
+		if name == "" || name == "-" {
+			continue
+		}
+		switch k := f.Type.Kind(); {
+		case k == reflect.Struct:
+			if err := checkStruct(f.Type); err != nil {
+				return err
+			}
+		case k == reflect.Slice && f.Type.Elem().Kind() == reflect.Uint8:
+		case k == reflect.String, k == reflect.Bool:
+		case reflect.Int <= k && k <= reflect.Float64:
+		default:
+			return &Error{ErrBadField, f.Name}
+		}
+	}
+	return nil
+}
+
+func decodeStruct(v reflect.Value, dir string, files map[string][]byte) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("doozer")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := dir + "/" + name
+		f := v.Field(i)
+		if f.Kind() == reflect.Struct && f.Type() != durationType {
+			if err := decodeStruct(f, path, files); err != nil {
+				return err
+			}
+			continue
+		}
+		body, ok := files[path]
+		if !ok {
+			continue
+		}
+		if err := decodeValue(f, body); err != nil {
+			return &Error{err, path}
+		}
+	}
+	return nil
+}
+
+func decodeValue(v reflect.Value, body []byte) error {
+	s := string(body)
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		v.SetBytes(append([]byte(nil), body...))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return ErrBadField
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package doozer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Breaker stops requests from being sent to a cluster that keeps
+// failing. After Threshold consecutive transient failures (see
+// IsTransient) it opens, and for the next Cooldown every request
+// fails at once with ErrUnavailable, rather than waiting on a dead
+// connection. Once the cooldown has passed, one request is let
+// through as a probe: if it succeeds the breaker closes, and if it
+// fails the breaker opens for another cooldown.
+//
+// Errors reported by the server count as successes; they show the
+// cluster is answering. Cancelled requests, and those failed by
+// closing the connection, are not counted. Nor are Waits, which may
+// rightly block for a long time; they fail at once while the breaker
+// is open, but are never sent as the probe.
+type Breaker struct {
+	// Threshold is the number of consecutive failures that
+	// opens the breaker. Zero means 5.
+	Threshold int
+
+	// Cooldown is how long the breaker stays open.
+	// Zero means 5 seconds.
+	Cooldown time.Duration
+
+	// OnChange, if set, is called when the breaker opens or closes.
+	OnChange func(open bool)
+
+	mu       sync.Mutex
+	failures int
+	until    time.Time // when an open breaker will allow a probe
+	open     bool
+	probing  bool
+}
+
+// Open reports whether b is failing requests.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// Reset closes b and forgets past failures.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	was := b.open
+	b.failures, b.open, b.probing = 0, false, false
+	b.mu.Unlock()
+	if was && b.OnChange != nil {
+		b.OnChange(false)
+	}
+}
+
+// allow reports whether a request may be sent.
+// A request allowed through must be followed by a call to record.
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.probing || time.Now().Before(b.until) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record notes the outcome of a request allowed by allow.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	was := b.open
+	switch {
+	case err == context.Canceled || err == context.DeadlineExceeded || err == ErrClosed:
+		// The caller gave up, or closed the connection;
+		// this says nothing about the cluster.
+	case IsTransient(err):
+		b.failures++
+		threshold := b.Threshold
+		if threshold <= 0 {
+			threshold = 5
+		}
+		if b.open || b.failures >= threshold {
+			cooldown := b.Cooldown
+			if cooldown <= 0 {
+				cooldown = 5 * time.Second
+			}
+			b.open = true
+			b.until = time.Now().Add(cooldown)
+		}
+	default:
+		b.failures = 0
+		b.open = false
+	}
+	b.probing = false
+	now := b.open
+	b.mu.Unlock()
+
+	if was != now && b.OnChange != nil {
+		b.OnChange(now)
+	}
+}
@@ -0,0 +1,140 @@
+package doozer
+
+import (
+	"context"
+	"sync"
+)
+
+// A Cache serves repeated reads of files from memory. Each cached
+// file is watched, and dropped from the cache as soon as it changes,
+// so a read never returns a body older than the last change the
+// Cache has been told of. A file that c's contracts require to be
+// Linearizable is never cached.
+type Cache struct {
+	// Negative, if set, caches that a file is missing too, until
+	// it is created.
+	Negative bool
+
+	c     *Conn
+	loads group
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	closed  bool
+}
+
+type cacheEntry struct {
+	body   []byte
+	rev    int64
+	err    error
+	cancel context.CancelFunc
+}
+
+// NewCache returns an empty Cache of the files at c.
+func NewCache(c *Conn) *Cache {
+	return &Cache{c: c, entries: make(map[string]*cacheEntry)}
+}
+
+// Get returns the body and revision of the file at path, as
+// Conn.Get does with a nil rev.
+func (k *Cache) Get(path string) ([]byte, int64, error) {
+	if k.c.Consistency(path) == Linearizable {
+		return k.c.Get(path, nil)
+	}
+
+	k.mu.Lock()
+	if k.closed {
+		k.mu.Unlock()
+		return nil, 0, ErrClosed
+	}
+	e, ok := k.entries[path]
+	k.mu.Unlock()
+
+	if !ok {
+		v, _, err := k.loads.do(path, func() (interface{}, error) {
+			return k.load(path)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		e = v.(*cacheEntry)
+	}
+	if e.err != nil {
+		return nil, 0, e.err
+	}
+	if e.body == nil {
+		return nil, e.rev, nil
+	}
+	return append([]byte(nil), e.body...), e.rev, nil
+}
+
+// Invalidate drops the file at path from the cache.
+func (k *Cache) Invalidate(path string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if e, ok := k.entries[path]; ok {
+		delete(k.entries, path)
+		e.cancel()
+	}
+}
+
+// Close empties the cache and stops watching the files in it.
+// Later calls to Get fail with ErrClosed.
+func (k *Cache) Close() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.closed = true
+	for path, e := range k.entries {
+		delete(k.entries, path)
+		e.cancel()
+	}
+}
+
+// load reads the file at path and, if it is to be cached, starts
+// watching it for changes.
+func (k *Cache) load(path string) (*cacheEntry, error) {
+	rev, err := k.c.Rev()
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(cacheEntry)
+	e.body, e.rev, err = k.c.Get(path, &rev)
+	if err, ok := err.(*Error); ok && err.Err == ErrNoEnt {
+		e.err = err
+	} else if err != nil {
+		return nil, err
+	}
+	if (e.err != nil || e.rev == missing) && !k.Negative {
+		return e, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		cancel()
+		return e, nil
+	}
+	if old, ok := k.entries[path]; ok {
+		old.cancel()
+	}
+	k.entries[path] = e
+	go k.watch(ctx, path, rev+1, e)
+	return e, nil
+}
+
+// watch drops e from the cache at the first change to path on or
+// after rev, or if it can no longer tell whether path has changed.
+func (k *Cache) watch(ctx context.Context, path string, rev int64, e *cacheEntry) {
+	k.c.WaitContext(ctx, path, rev)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.entries[path] == e {
+		delete(k.entries, path)
+	}
+	e.cancel()
+}
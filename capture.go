@@ -0,0 +1,185 @@
+package doozer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"sync"
+)
+
+var (
+	ErrBadCapture = errors.New("malformed capture")
+)
+
+// Captures are written by a Conn whose Config has Capture set, and
+// read by NewReplay. A capture is a sequence of records, one for
+// each frame sent or received, in the order the Conn saw them:
+//
+//	<dir> <len> <frame>
+//
+// where <dir> is the byte '>' for a request or '<' for a response,
+// <len> is the length of the frame as a 4-byte big-endian integer,
+// and <frame> is the encoded message.
+const (
+	captureRequest  = '>'
+	captureResponse = '<'
+)
+
+// capture writes a record of a frame to l.cfg.Capture, if set.
+func (l *link) capture(dir byte, buf []byte) {
+	w := l.cfg.Capture
+	if w == nil {
+		return
+	}
+
+	var hdr [5]byte
+	hdr[0] = dir
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(buf)))
+	l.tmu.Lock()
+	defer l.tmu.Unlock()
+	w.Write(hdr[:])
+	w.Write(buf)
+}
+
+// A Replay stands in for the server recorded in a capture, so that
+// a session seen in the field can be run again in a test.
+//
+// Each request is answered with the responses the server sent to the
+// first unanswered captured request that is the same but for its tag.
+// A request that matches none fails with ErrOther.
+type Replay struct {
+	mu    sync.Mutex
+	reqs  [][]byte   // captured requests, without tags
+	resps [][][]byte // captured responses to each request
+	used  []bool     // whether each request has been answered
+}
+
+// NewReplay reads a capture from r.
+func NewReplay(r io.Reader) (*Replay, error) {
+	rp := new(Replay)
+	last := make(map[int32]int) // tag -> latest request with it
+	for {
+		var hdr [5]byte
+		_, err := io.ReadFull(r, hdr[:])
+		if err == io.EOF {
+			return rp, nil
+		}
+		if err != nil {
+			return nil, ErrBadCapture
+		}
+		buf := make([]byte, binary.BigEndian.Uint32(hdr[1:]))
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, ErrBadCapture
+		}
+
+		switch hdr[0] {
+		case captureRequest:
+			var req request
+			if proto.Unmarshal(buf, &req) != nil || req.Tag == nil {
+				return nil, ErrBadCapture
+			}
+			last[*req.Tag] = len(rp.reqs)
+			rp.reqs = append(rp.reqs, untagged(&req))
+			rp.resps = append(rp.resps, nil)
+			rp.used = append(rp.used, false)
+		case captureResponse:
+			var resp response
+			if proto.Unmarshal(buf, &resp) != nil {
+				return nil, ErrBadCapture
+			}
+			if resp.Tag == nil {
+				continue
+			}
+			i, ok := last[*resp.Tag]
+			if !ok {
+				continue
+			}
+			rp.resps[i] = append(rp.resps[i], buf)
+		default:
+			return nil, ErrBadCapture
+		}
+	}
+}
+
+// untagged returns the encoding of req without its tag.
+func untagged(req *request) []byte {
+	cp := proto.Clone(req).(*request)
+	cp.Tag = nil
+	buf, _ := proto.Marshal(cp)
+	return buf
+}
+
+// Conn returns a new Conn whose requests are answered by rp.
+func (rp *Replay) Conn() *Conn {
+	client, server := net.Pipe()
+	go rp.serve(server)
+	return NewConn(client, nil)
+}
+
+// answers returns the captured responses to the first unanswered
+// request like req.
+func (rp *Replay) answers(req *request) ([][]byte, bool) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	key := untagged(req)
+	for i, b := range rp.reqs {
+		if !rp.used[i] && bytes.Equal(b, key) {
+			rp.used[i] = true
+			return rp.resps[i], true
+		}
+	}
+	return nil, false
+}
+
+func (rp *Replay) serve(nc net.Conn) {
+	defer nc.Close()
+
+	for {
+		var size int32
+		err := binary.Read(nc, binary.BigEndian, &size)
+		if err != nil {
+			return
+		}
+		buf := make([]byte, size)
+		_, err = io.ReadFull(nc, buf)
+		if err != nil {
+			return
+		}
+
+		var req request
+		err = proto.Unmarshal(buf, &req)
+		if err != nil || req.Verb == nil {
+			return
+		}
+
+		var out []*response
+		bufs, ok := rp.answers(&req)
+		if !ok {
+			verb := request_Verb_name[int32(*req.Verb)]
+			out = append(out, &response{
+				ErrCode:   ErrOther.Enum(),
+				ErrDetail: proto.String("no captured request matches " + verb + " " + req.GetPath()),
+			})
+		}
+		for _, b := range bufs {
+			var resp response
+			proto.Unmarshal(b, &resp)
+			out = append(out, &resp)
+		}
+
+		for _, resp := range out {
+			resp.Tag = req.Tag
+			buf, err := proto.Marshal(resp)
+			if err != nil {
+				return
+			}
+			binary.Write(nc, binary.BigEndian, int32(len(buf)))
+			nc.Write(buf)
+		}
+	}
+}
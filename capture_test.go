@@ -0,0 +1,152 @@
+package doozer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"github.com/dcjones/doozer/internal/msg"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"testing"
+)
+
+// serve answers the requests on nc from c, speaking the wire
+// protocol for the verbs a session below uses.
+func serve(nc net.Conn, c *doozertest.Client) {
+	defer nc.Close()
+
+	for {
+		var size int32
+		if binary.Read(nc, binary.BigEndian, &size) != nil {
+			return
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(nc, buf); err != nil {
+			return
+		}
+		var req msg.Request
+		if proto.Unmarshal(buf, &req) != nil {
+			return
+		}
+
+		resp := &msg.Response{Tag: req.Tag, Flags: proto.Int32(1)}
+		var err error
+		switch req.GetVerb() {
+		case msg.Request_GET:
+			var rev int64
+			resp.Value, rev, err = c.Get(req.GetPath(), req.Rev)
+			resp.Rev = &rev
+		case msg.Request_SET:
+			var rev int64
+			rev, err = c.Set(req.GetPath(), req.GetRev(), req.Value)
+			resp.Rev = &rev
+		case msg.Request_DEL:
+			err = c.Del(req.GetPath(), req.GetRev())
+		case msg.Request_REV:
+			var rev int64
+			rev, err = c.Rev()
+			resp.Rev = &rev
+		default:
+			err = &doozer.Error{Err: msg.Response_UNKNOWN_VERB}
+		}
+		var e *doozer.Error
+		if errors.As(err, &e) {
+			code, ok := e.Err.(msg.Response_Err)
+			if !ok {
+				code = msg.Response_OTHER
+			}
+			resp = &msg.Response{Tag: req.Tag, ErrCode: code.Enum(), ErrDetail: proto.String(e.Detail)}
+		}
+
+		buf, _ = proto.Marshal(resp)
+		binary.Write(nc, binary.BigEndian, int32(len(buf)))
+		nc.Write(buf)
+	}
+}
+
+// session runs a fixed sequence of calls on c, and returns what
+// each of them returned.
+func session(c *doozer.Conn) []string {
+	var out []string
+	note := func(v ...interface{}) {
+		out = append(out, fmt.Sprint(v...))
+	}
+
+	rev, err := c.Set("/a", 0, []byte("1"))
+	note(rev, err)
+	rev, err = c.Set("/a", rev, []byte("2"))
+	note(rev, err)
+	_, err = c.Set("/a", 0, []byte("3"))
+	note(err)
+	body, rev, err := c.Get("/a", nil)
+	note(string(body), rev, err)
+	body, rev, err = c.Get("/a", proto.Int64(1))
+	note(string(body), rev, err)
+	note(c.Del("/a", -1))
+	body, rev, err = c.Get("/a", nil)
+	note(string(body), rev, err)
+	rev, err = c.Rev()
+	note(rev, err)
+	return out
+}
+
+func TestCaptureReplay(t *testing.T) {
+	s := doozertest.NewServer()
+	client, server := net.Pipe()
+	go serve(server, s.Client())
+
+	var capture bytes.Buffer
+	c := doozer.NewConn(client, &doozer.Config{Capture: &capture})
+	want := session(c)
+	c.Close()
+
+	rp, err := doozer.NewReplay(&capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = rp.Conn()
+	defer c.Close()
+	got := session(c)
+
+	if len(got) != len(want) {
+		t.Fatalf("replay gave %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d: replay gave %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	s := doozertest.NewServer()
+	client, server := net.Pipe()
+	go serve(server, s.Client())
+
+	var capture bytes.Buffer
+	c := doozer.NewConn(client, &doozer.Config{Capture: &capture})
+	if _, err := c.Set("/a", 0, []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	rp, err := doozer.NewReplay(&capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = rp.Conn()
+	defer c.Close()
+
+	// Each captured request is answered once.
+	if _, err := c.Set("/a", 0, []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Set("/a", 0, []byte("1"))
+	if e, ok := err.(*doozer.Error); !ok || e.Err != doozer.ErrOther {
+		t.Fatalf("second Set: got %v, want ErrOther", err)
+	}
+}
@@ -0,0 +1,68 @@
+package doozer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// checksumHeader begins each body written by Checksum, followed by
+// the CRC-32C of the rest of the body, in big-endian order.
+const checksumHeader = "\x00dz\x01ck"
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum returns an Interceptor that prefixes each body written by
+// SET with a short header holding its checksum, and verifies and
+// strips the header from each body in responses. A body whose
+// checksum does not match, as when it has been truncated or partly
+// overwritten, fails with ErrCorrupt. Bodies without a header, such
+// as those written by clients not using Checksum, are returned as
+// they are.
+//
+// Used together with Compress, Checksum should come first, so that
+// it covers the original body.
+func Checksum() Interceptor {
+	return func(ctx context.Context, req *Request, next Invoker) (*Response, error) {
+		if req.Verb == "SET" {
+			r := *req
+			r.Value = addChecksum(req.Value)
+			req = &r
+		}
+		resp, err := next(ctx, req)
+		if err != nil || !bytes.HasPrefix(resp.Value, []byte(checksumHeader)) {
+			return resp, err
+		}
+		body, ok := checkChecksum(resp.Value)
+		if !ok {
+			path := resp.Path
+			if path == "" {
+				path = req.Path
+			}
+			return nil, &Error{ErrCorrupt, path}
+		}
+		r := *resp
+		r.Value = body
+		return &r, nil
+	}
+}
+
+func addChecksum(body []byte) []byte {
+	b := make([]byte, len(checksumHeader)+4, len(checksumHeader)+4+len(body))
+	copy(b, checksumHeader)
+	binary.BigEndian.PutUint32(b[len(checksumHeader):], crc32.Checksum(body, castagnoli))
+	return append(b, body...)
+}
+
+// checkChecksum returns the body behind the header, and whether it
+// matches the checksum in the header.
+func checkChecksum(b []byte) ([]byte, bool) {
+	n := len(checksumHeader) + 4
+	if len(b) < n {
+		return nil, false
+	}
+	sum := binary.BigEndian.Uint32(b[len(checksumHeader):])
+	body := b[n:]
+	return body, crc32.Checksum(body, castagnoli) == sum
+}
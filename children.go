@@ -0,0 +1,148 @@
+package doozer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A ChildEvent reports that an entry was added to or removed from
+// a directory watched by a ChildWatcher.
+type ChildEvent struct {
+	Name  string
+	Added bool // false means removed
+	Rev   int64
+}
+
+// A ChildWatcher follows the entries in a directory, such as the
+// members of a service registered beneath it.
+type ChildWatcher struct {
+	// C delivers each change to the entries, in order.
+	C <-chan ChildEvent
+
+	c      *Conn
+	dir    string
+	cancel CancelFunc
+	done   chan bool
+	once   sync.Once
+
+	mu    sync.Mutex
+	names map[string]bool
+	rev   int64
+	err   error
+}
+
+// WatchChildren lists the entries in dir at the current revision
+// and then follows them, reporting additions and removals on C.
+// A missing dir has no entries.
+func (c *Conn) WatchChildren(dir string) (*ChildWatcher, error) {
+	rev, err := c.Rev()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := c.Getdir(dir, rev, 0, -1)
+	if err, ok := err.(*Error); ok && err.Err == ErrNoEnt {
+		names, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	glob := strings.TrimSuffix(dir, "/") + "/**"
+	evs, cancel, err := c.Watch(glob, rev+1)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ChildEvent)
+	w := &ChildWatcher{
+		C:      ch,
+		c:      c,
+		dir:    strings.TrimSuffix(dir, "/"),
+		cancel: cancel,
+		done:   make(chan bool),
+		names:  make(map[string]bool),
+		rev:    rev,
+	}
+	for _, name := range names {
+		w.names[name] = true
+	}
+	go w.run(evs, ch)
+	return w, nil
+}
+
+// Children returns the entries in the directory, in order, and the
+// revision they were seen at. The result may already include a
+// change whose event has yet to be received from C.
+func (w *ChildWatcher) Children() (names []string, rev int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for name := range w.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, w.rev
+}
+
+// Close stops following the directory and closes C. It returns the
+// error that had already ended the watch, if any.
+func (w *ChildWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	err := w.cancel()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	return err
+}
+
+func (w *ChildWatcher) run(evs <-chan Event, ch chan ChildEvent) {
+	defer close(ch)
+	for ev := range evs {
+		name := strings.TrimPrefix(ev.Path, w.dir+"/")
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+		}
+
+		w.mu.Lock()
+		had := w.names[name]
+		w.mu.Unlock()
+
+		var has bool
+		if ev.IsSet() {
+			has = true
+		} else if had {
+			// A deletion may leave other files beneath the entry.
+			_, frev, err := w.c.Stat(w.dir+"/"+name, &ev.Rev)
+			if err != nil {
+				w.mu.Lock()
+				w.err = err
+				w.mu.Unlock()
+				w.cancel()
+				return
+			}
+			has = frev != missing
+		}
+
+		w.mu.Lock()
+		w.rev = ev.Rev
+		if has == had {
+			w.mu.Unlock()
+			continue
+		}
+		if has {
+			w.names[name] = true
+		} else {
+			delete(w.names, name)
+		}
+		w.mu.Unlock()
+
+		select {
+		case ch <- ChildEvent{name, has, ev.Rev}:
+		case <-w.done:
+			return
+		}
+	}
+}
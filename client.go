@@ -0,0 +1,54 @@
+package doozer
+
+import (
+	"context"
+)
+
+// A Client is a connection to a doozer store. *Conn is a Client;
+// applications can depend on Client instead, to substitute a fake
+// in tests or to wrap a Conn with extra behavior.
+type Client interface {
+	Access(token string) error
+	Get(file string, rev *int64) ([]byte, int64, error)
+	Set(file string, oldRev int64, body []byte) (newRev int64, err error)
+	Del(file string, rev int64) error
+	Getdir(dir string, rev int64, off, lim int) (names []string, err error)
+	Stat(path string, storeRev *int64) (len int, fileRev int64, err error)
+	Walk(glob string, rev int64, off, lim int) (info []Event, err error)
+	Wait(glob string, rev int64) (ev Event, err error)
+	Rev() (int64, error)
+	Close()
+}
+
+var _ Client = (*Conn)(nil)
+
+// A contextWaiter is a Client whose Wait can be given up on, as
+// Conn's WaitContext can.
+type contextWaiter interface {
+	WaitContext(ctx context.Context, glob string, rev int64) (Event, error)
+}
+
+// waitContext waits as c.Wait does, giving up when ctx is done. A
+// Client without a WaitContext method is left to finish its Wait in
+// the background.
+func waitContext(ctx context.Context, c Client, glob string, rev int64) (Event, error) {
+	if c, ok := c.(contextWaiter); ok {
+		return c.WaitContext(ctx, glob, rev)
+	}
+
+	type result struct {
+		ev  Event
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		ev, err := c.Wait(glob, rev)
+		ch <- result{ev, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.ev, r.err
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	}
+}
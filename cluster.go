@@ -0,0 +1,368 @@
+package doozer
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// A Cluster holds connections to several members of a doozer
+// cluster. Writes, waits, and listings go to the first member, the
+// primary. Get and Rev may be hedged: if the primary hasn't answered
+// after Hedge, the same request is sent to a second member, the first
+// response is used, and the other request is abandoned.
+//
+// In quorum mode, reads are instead sent to a majority of members and
+// their results compared, to detect a member that has split from the
+// others or fallen behind.
+type Cluster struct {
+	// Hedge is how long Get and Rev wait for the primary before
+	// asking another member. Zero means never hedge.
+	Hedge time.Duration
+
+	// Quorum makes Get, Getdir, Stat, and Walk read from a majority
+	// of members, at the same revision, and fail with ErrDiverged if
+	// their results differ. A read without a revision is made at the
+	// primary's current revision. Rev is unaffected, since members
+	// may rightly be at different revisions.
+	Quorum bool
+
+	conns []*Conn
+}
+
+var _ Client = (*Cluster)(nil)
+
+// DialCluster connects to each of addrs, using the options in cfg.
+// The first address is the primary.
+func DialCluster(addrs []string, cfg *Config) (*Cluster, error) {
+	if len(addrs) == 0 {
+		return nil, ErrNoAddrs
+	}
+
+	cl := new(Cluster)
+	for _, addr := range addrs {
+		c, err := DialConfig(addr, cfg)
+		if err != nil {
+			cl.Close()
+			return nil, err
+		}
+		cl.conns = append(cl.conns, c)
+	}
+	return cl, nil
+}
+
+// DialClusterUri is like DialCluster, but connects to each of the
+// servers given in uri, as DialUri would find them.
+func DialClusterUri(uri, buri string, cfg *Config) (*Cluster, error) {
+	addrs, p, err := resolveUri(uri, buri, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := DialCluster(addrs, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, ok := p["sk"]
+	if ok {
+		err = cl.Access(secret[0])
+		if err != nil {
+			cl.Close()
+			return nil, err
+		}
+	}
+	return cl, nil
+}
+
+// Conns returns the connections to the members of cl,
+// the primary first.
+func (cl *Cluster) Conns() []*Conn {
+	return append([]*Conn(nil), cl.conns...)
+}
+
+func (cl *Cluster) primary() *Conn {
+	return cl.conns[0]
+}
+
+// Consistency returns the consistency the primary's
+// contracts require of reads of path.
+func (cl *Cluster) Consistency(path string) Consistency {
+	return cl.primary().Consistency(path)
+}
+
+// hedge calls f with the primary, and, if it has not returned after
+// cl.Hedge, with a second member too. It returns the first result to
+// succeed, or the last error if both fail, and cancels the other call.
+// An error reported by the server is returned at once.
+func (cl *Cluster) hedge(f func(ctx context.Context, c *Conn) (interface{}, error)) (interface{}, error) {
+	if cl.Hedge <= 0 || len(cl.conns) < 2 {
+		return f(context.Background(), cl.primary())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+	ch := make(chan result, 2)
+	run := func(c *Conn) {
+		v, err := f(ctx, c)
+		ch <- result{v, err}
+	}
+
+	go run(cl.primary())
+	timer := time.NewTimer(cl.Hedge)
+	defer timer.Stop()
+
+	n := 1
+	select {
+	case r := <-ch:
+		if !IsTransient(r.err) {
+			return r.v, r.err
+		}
+		// The primary failed outright; ask the other member now.
+		go run(cl.conns[1])
+		r = <-ch
+		return r.v, r.err
+	case <-timer.C:
+		go run(cl.conns[1])
+		n++
+	}
+
+	var r result
+	for ; n > 0; n-- {
+		r = <-ch
+		if !IsTransient(r.err) {
+			return r.v, r.err
+		}
+	}
+	return r.v, r.err
+}
+
+// quorum calls f with each of a majority of members, the primary
+// first, and returns its result if every call returns the same one.
+// Otherwise it returns the first error, or ErrDiverged.
+func (cl *Cluster) quorum(path string, f func(c *Conn) (interface{}, error), same func(a, b interface{}) bool) (interface{}, error) {
+	type result struct {
+		v   interface{}
+		err error
+	}
+	rs := make([]chan result, len(cl.conns)/2+1)
+	for i := range rs {
+		rs[i] = make(chan result, 1)
+		go func(c *Conn, ch chan result) {
+			v, err := f(c)
+			ch <- result{v, err}
+		}(cl.conns[i], rs[i])
+	}
+
+	var first result
+	diverged := false
+	for i, ch := range rs {
+		r := <-ch
+		switch {
+		case i == 0:
+			first = r
+		case first.err != nil:
+		case r.err != nil:
+			first.err = r.err
+		case !same(first.v, r.v):
+			diverged = true
+		}
+	}
+	if first.err != nil {
+		return nil, first.err
+	}
+	if diverged {
+		return nil, &Error{Err: ErrDiverged, Detail: path}
+	}
+	return first.v, nil
+}
+
+// pin returns rev, or the primary's current revision if rev is nil.
+func (cl *Cluster) pin(rev *int64) (*int64, error) {
+	if rev != nil {
+		return rev, nil
+	}
+	r, err := cl.primary().Rev()
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (cl *Cluster) Access(token string) error {
+	for _, c := range cl.conns {
+		err := c.Access(token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get is like Conn.Get, but is hedged, or made by quorum. Outside
+// quorum mode, a path that the contracts require to be read
+// linearizably is only read from the primary.
+func (cl *Cluster) Get(file string, rev *int64) ([]byte, int64, error) {
+	type result struct {
+		body []byte
+		rev  int64
+	}
+	if cl.Quorum {
+		rev, err := cl.pin(rev)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, err := cl.quorum(file, func(c *Conn) (interface{}, error) {
+			body, rev, err := c.Get(file, rev)
+			return result{body, rev}, err
+		}, func(a, b interface{}) bool {
+			x, y := a.(result), b.(result)
+			return x.rev == y.rev && bytes.Equal(x.body, y.body)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		r := v.(result)
+		return r.body, r.rev, nil
+	}
+
+	if rev == nil && cl.Consistency(file) == Linearizable {
+		return cl.primary().Get(file, rev)
+	}
+
+	v, err := cl.hedge(func(ctx context.Context, c *Conn) (interface{}, error) {
+		body, rev, err := c.get(ctx, file, rev)
+		return result{body, rev}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	r := v.(result)
+	return r.body, r.rev, nil
+}
+
+func (cl *Cluster) Set(file string, oldRev int64, body []byte) (int64, error) {
+	return cl.primary().Set(file, oldRev, body)
+}
+
+func (cl *Cluster) Del(file string, rev int64) error {
+	return cl.primary().Del(file, rev)
+}
+
+func (cl *Cluster) Getdir(dir string, rev int64, off, lim int) ([]string, error) {
+	if !cl.Quorum {
+		return cl.primary().Getdir(dir, rev, off, lim)
+	}
+
+	v, err := cl.quorum(dir, func(c *Conn) (interface{}, error) {
+		return c.Getdir(dir, rev, off, lim)
+	}, func(a, b interface{}) bool {
+		return sameNames(a.([]string), b.([]string))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+func (cl *Cluster) Stat(path string, storeRev *int64) (int, int64, error) {
+	if !cl.Quorum {
+		return cl.primary().Stat(path, storeRev)
+	}
+
+	type result struct {
+		len int
+		rev int64
+	}
+	storeRev, err := cl.pin(storeRev)
+	if err != nil {
+		return 0, 0, err
+	}
+	v, err := cl.quorum(path, func(c *Conn) (interface{}, error) {
+		len, rev, err := c.Stat(path, storeRev)
+		return result{len, rev}, err
+	}, func(a, b interface{}) bool {
+		return a.(result) == b.(result)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	r := v.(result)
+	return r.len, r.rev, nil
+}
+
+func (cl *Cluster) Walk(glob string, rev int64, off, lim int) ([]Event, error) {
+	if !cl.Quorum {
+		return cl.primary().Walk(glob, rev, off, lim)
+	}
+
+	v, err := cl.quorum(glob, func(c *Conn) (interface{}, error) {
+		return c.Walk(glob, rev, off, lim)
+	}, func(a, b interface{}) bool {
+		x, y := a.([]Event), b.([]Event)
+		if len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if x[i].Rev != y[i].Rev || x[i].Path != y[i].Path ||
+				x[i].Flag != y[i].Flag || !bytes.Equal(x[i].Body, y[i].Body) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Event), nil
+}
+
+func (cl *Cluster) Wait(glob string, rev int64) (Event, error) {
+	return cl.primary().Wait(glob, rev)
+}
+
+// WaitContext is like Conn.WaitContext, waiting on the primary.
+func (cl *Cluster) WaitContext(ctx context.Context, glob string, rev int64) (Event, error) {
+	return cl.primary().WaitContext(ctx, glob, rev)
+}
+
+// Rev is like Conn.Rev, but is hedged. If the contracts require any
+// path to be read linearizably, it asks only the primary, since a
+// lagging member's revision, used to pin later reads, would make
+// them stale.
+func (cl *Cluster) Rev() (int64, error) {
+	if p := cl.primary(); p.cfg.Contracts.linearizable() {
+		return p.Rev()
+	}
+	v, err := cl.hedge(func(ctx context.Context, c *Conn) (interface{}, error) {
+		return c.rev(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// Close closes the connection to every member.
+func (cl *Cluster) Close() {
+	for _, c := range cl.conns {
+		c.Close()
+	}
+}
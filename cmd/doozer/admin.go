@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dcjones/doozer"
+	"os"
+	"sort"
+	"strings"
+)
+
+func init() {
+	cmds["admin"] = cmd{admin, "<subcommand> [args]", "administer the cluster"}
+	cmdHelp["admin"] = `Inspects and changes the cluster's control files under /ctl.
+
+Subcommands:
+
+  members <cluster>           - list the members in <cluster>'s namespace
+  add <cluster> <id> <addr>   - register <addr> as member <id> of <cluster>
+  rm <cluster> <id>           - remove member <id> from <cluster>'s namespace
+  cal                         - list the calendar slots and their nodes
+  nominate <slot>             - add open calendar slot <slot> for a node to take
+  demote <node>               - remove <node>'s slot from the calendar
+  nodes                       - list the nodes and their addresses
+  node <id>                   - print the information kept by node <id>
+
+Commands that make changes ask for confirmation first,
+unless flag -y is given.
+`
+}
+
+type adminCmd struct {
+	f func(c *doozer.Conn, args []string)
+	n int // number of args
+}
+
+var adminCmds = map[string]adminCmd{
+	"members":  {adminMembers, 1},
+	"add":      {adminAdd, 3},
+	"rm":       {adminRm, 2},
+	"cal":      {adminCal, 0},
+	"nominate": {adminNominate, 1},
+	"demote":   {adminDemote, 1},
+	"nodes":    {adminNodes, 0},
+	"node":     {adminNode, 1},
+}
+
+func admin(args ...string) {
+	if len(args) < 1 {
+		help("admin")
+		os.Exit(127)
+	}
+
+	ac, ok := adminCmds[args[0]]
+	if !ok || len(args)-1 != ac.n {
+		fmt.Fprintf(os.Stderr, "admin: bad subcommand or arguments: %s\n", strings.Join(args, " "))
+		help("admin")
+		os.Exit(127)
+	}
+
+	c := dial()
+	ac.f(c, args[1:])
+}
+
+// confirm asks the user to confirm msg on the terminal.
+func confirm(msg string) bool {
+	if *assumeYes {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", msg)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.ToLower(strings.TrimSpace(line))
+	return line == "y" || line == "yes"
+}
+
+func adminMembers(c *doozer.Conn, args []string) {
+	ms, err := doozer.Members(c, args[0], storeRev(c))
+	if err != nil {
+		bail(err)
+	}
+	for _, m := range ms {
+		fmt.Println(m.ID, m.Addr, m.Rev)
+	}
+}
+
+func adminAdd(c *doozer.Conn, args []string) {
+	cluster, id, addr := args[0], args[1], args[2]
+	if !confirm(fmt.Sprintf("Add member %s at %s to cluster %s?", id, addr, cluster)) {
+		os.Exit(1)
+	}
+
+	rev, err := doozer.AddMember(c, cluster, id, addr)
+	if err != nil {
+		bail(err)
+	}
+	fmt.Println(rev)
+}
+
+func adminRm(c *doozer.Conn, args []string) {
+	cluster, id := args[0], args[1]
+
+	ms, err := doozer.Members(c, cluster, storeRev(c))
+	if err != nil {
+		bail(err)
+	}
+	for _, m := range ms {
+		if m.ID != id {
+			continue
+		}
+		if !confirm(fmt.Sprintf("Remove member %s at %s from cluster %s?", id, m.Addr, cluster)) {
+			os.Exit(1)
+		}
+		err = doozer.RemoveMember(c, cluster, id, m.Rev)
+		if err != nil {
+			bail(err)
+		}
+		return
+	}
+	bail(fmt.Errorf("no member %s in cluster %s", id, cluster))
+}
+
+func adminCal(c *doozer.Conn, args []string) {
+	slots, err := doozer.CalSlots(c, storeRev(c))
+	if err != nil {
+		bail(err)
+	}
+	for _, s := range slots {
+		node := s.Node
+		if node == "" {
+			node = "-"
+		}
+		fmt.Println(s.Slot, node, s.Rev)
+	}
+}
+
+func adminNominate(c *doozer.Conn, args []string) {
+	n := int(mustAtoi64(args[0]))
+	if !confirm(fmt.Sprintf("Add open slot %d to the calendar?", n)) {
+		os.Exit(1)
+	}
+
+	rev, err := doozer.NominateSlot(c, n)
+	if err != nil {
+		bail(err)
+	}
+	fmt.Println(rev)
+}
+
+func adminDemote(c *doozer.Conn, args []string) {
+	node := args[0]
+	if !confirm(fmt.Sprintf("Remove node %s from the calendar?", node)) {
+		os.Exit(1)
+	}
+
+	slot, err := doozer.Demote(c, node, storeRev(c))
+	if err != nil {
+		bail(err)
+	}
+	fmt.Println(slot)
+}
+
+func adminNodes(c *doozer.Conn, args []string) {
+	nodes, err := doozer.Nodes(c, storeRev(c))
+	if err != nil {
+		bail(err)
+	}
+	for _, n := range nodes {
+		fmt.Println(n.ID, n.Addr)
+	}
+}
+
+func adminNode(c *doozer.Conn, args []string) {
+	n, err := doozer.NodeInfo(c, args[0], storeRev(c))
+	if err != nil {
+		bail(err)
+	}
+	var keys []string
+	for k := range n.Info {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Println(k, n.Info[k])
+	}
+}
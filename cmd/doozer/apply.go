@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dcjones/doozer"
+	"os"
+)
+
+func init() {
+	cmds["apply"] = cmd{apply, "", "apply a patch"}
+	cmdHelp["apply"] = `Applies a patch read from stdin.
+
+The patch is a sequence of records, one for each change. Format of each record:
+
+  <path> <oldrev> <set|del> <len> LF <body> LF
+
+Here, <path> is the file's path, <oldrev> is the revision the file must
+not have been modified since (0 if it must not exist), <len> is the number
+of bytes in the body, and LF is an ASCII line-feed char.
+
+Changes are made in order, each one only if its file has not been
+modified since <oldrev>. Applying stops at the first change that cannot
+be made. Prints the number of changes made on stdout.
+`
+}
+
+func apply() {
+	changes, err := doozer.ReadPatch(os.Stdin)
+	if err != nil {
+		bail(err)
+	}
+
+	c := dial()
+
+	n, err := doozer.Apply(c, changes)
+	fmt.Println(n)
+	if err != nil {
+		bail(err)
+	}
+}
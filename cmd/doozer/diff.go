@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dcjones/doozer"
+)
+
+func init() {
+	cmds["diff"] = cmd{diff, "<glob> <rev-a> <rev-b>", "list files changed between revisions"}
+	cmdHelp["diff"] = `Lists the files matching <glob> that differ between revisions
+<rev-a> and <rev-b>, one per line, in order by path.
+
+Each line is the path preceded by "+" if the file was added, "-" if
+it was deleted, or "~" if it was changed.
+`
+}
+
+func diff(glob, a, b string) {
+	revA, revB := mustAtoi64(a), mustAtoi64(b)
+
+	c := dial()
+
+	changes, err := doozer.Diff(c, glob, revA, revB)
+	if err != nil {
+		bail(err)
+	}
+
+	for _, ch := range changes {
+		op := "~"
+		switch {
+		case ch.Del:
+			op = "-"
+		case ch.Added():
+			op = "+"
+		}
+		fmt.Println(op, ch.Path)
+	}
+}
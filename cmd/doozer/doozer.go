@@ -4,10 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
 	"os"
 	"reflect"
 	"sort"
 	"strconv"
+	"text/template"
 )
 
 var (
@@ -16,6 +18,11 @@ var (
 	rrev        = flag.Int64("r", -1, "request rev")
 	showHelp    = flag.Bool("h", false, "show help")
 	showVersion = flag.Bool("v", false, "print version string")
+	outFormat   = flag.String("f", "", "output format: csv, json, protobuf, or text")
+	outTemplate = flag.String("t", "", "output template, overriding -f")
+	assumeYes   = flag.Bool("y", false, "answer yes to confirmation prompts")
+	secret      = flag.String("s", "", "the secret to present to the server")
+	timeout     = flag.Duration("timeout", 0, "time limit on dialing and on each read and write")
 )
 
 type cmd struct {
@@ -44,6 +51,8 @@ Global Options:
 
   DOOZER_BOOT_URI - The DzNS to lookup address in; overriden by -b.
 
+  DOOZER_SECRET - The secret to present to the server; overriden by -s.
+
 Commands:
 `
 )
@@ -89,14 +98,55 @@ func mustAtoi64(arg string) int64 {
 	return n
 }
 
+// formatter returns a Formatter writing to stdout,
+// as directed by the -f and -t flags.
+func formatter() format.Formatter {
+	if *outTemplate != "" {
+		t, err := template.New("out").Parse(*outTemplate)
+		if err != nil {
+			bail(err)
+		}
+		return format.Template(t)(os.Stdout)
+	}
+
+	name := *outFormat
+	if name == "" {
+		name = "text"
+	}
+	f, err := format.New(name, os.Stdout)
+	if err != nil {
+		bail(fmt.Errorf("%s: %v", name, err))
+	}
+	return f
+}
+
 func dial() *doozer.Conn {
-	c, err := doozer.DialUri(*uri, *buri)
+	c, err := doozer.DialUriTimeout(*uri, *buri, *timeout)
 	if err != nil {
 		bail(err)
 	}
+	if *secret != "" {
+		err = c.Access(*secret)
+		if err != nil {
+			bail(err)
+		}
+	}
 	return c
 }
 
+// storeRev returns the revision given by the -r flag,
+// or else the current revision of c.
+func storeRev(c *doozer.Conn) int64 {
+	if *rrev != -1 {
+		return *rrev
+	}
+	rev, err := c.Rev()
+	if err != nil {
+		bail(err)
+	}
+	return rev
+}
+
 func main() {
 	if e := os.Getenv("DOOZER_URI"); e != "" {
 		*uri = e
@@ -106,6 +156,10 @@ func main() {
 		*buri = e
 	}
 
+	if e := os.Getenv("DOOZER_SECRET"); e != "" {
+		*secret = e
+	}
+
 	flag.Usage = usage
 	flag.Parse()
 
@@ -144,7 +198,7 @@ func main() {
 
 	args := flag.Args()
 	ft := reflect.TypeOf(c.f)
-	if len(args) != ft.NumIn() {
+	if ft.IsVariadic() && len(args) < ft.NumIn()-1 || !ft.IsVariadic() && len(args) != ft.NumIn() {
 		fmt.Fprintf(os.Stderr, "%s: wrong number of arguments\n", cmd)
 		help(cmd)
 		os.Exit(127)
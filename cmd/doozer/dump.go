@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/dcjones/doozer"
+	"os"
+)
+
+func init() {
+	cmds["dump"] = cmd{dump, "<glob>", "write files to a dump"}
+	cmdHelp["dump"] = `Writes every file matching <glob> to stdout as a dump, to be read
+by restore.
+
+If flag -r is given, dumps the files as of <rev>.
+
+The dump has a line for each file, a JSON object holding its path,
+its rev, and its body encoded in base64.
+`
+}
+
+func dump(glob string) {
+	c := dial()
+
+	_, err := doozer.Dump(c, glob, storeRev(c), os.Stdout)
+	if err != nil {
+		bail(err)
+	}
+}
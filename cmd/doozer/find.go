@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
 	"os"
 )
 
@@ -11,6 +12,8 @@ func init() {
 	cmdHelp["find"] = `Prints the tree rooted at <path>
 
 Prints the path for each file or directory, one per line.
+If flag -f or -t is given, prints a record of the given format
+for each file or directory instead.
 `
 }
 
@@ -25,13 +28,23 @@ func find(path string) {
 		}
 	}
 
+	var out format.Formatter
+	if *outFormat != "" || *outTemplate != "" {
+		out = formatter()
+		defer out.Flush()
+	}
+
 	v := func(path string, f *doozer.FileInfo, e error) (err error) {
 		if e != nil {
 			fmt.Fprintln(os.Stderr, err)
 			return
 		}
 
-		fmt.Println(path)
+		if out != nil {
+			out.Write(format.FromFileInfo(path, f))
+		} else {
+			fmt.Println(path)
+		}
 
 		return nil
 	}
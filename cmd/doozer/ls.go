@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+)
+
+func init() {
+	cmds["ls"] = cmd{ls, "<path>", "list a directory"}
+	cmdHelp["ls"] = `Prints the names of the entries in the directory at <path>,
+one per line.
+
+If flag -r is given, lists the directory as of <rev>.
+`
+}
+
+func ls(path string) {
+	c := dial()
+
+	names, err := c.Getdir(path, storeRev(c), 0, -1)
+	if err != nil {
+		bail(err)
+	}
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dcjones/doozer"
+	"os"
+)
+
+func init() {
+	cmds["restore"] = cmd{restore, "[overwrite|skip|strict]", "write files from a dump"}
+	cmdHelp["restore"] = `Writes the files in a dump, written by dump, read from stdin.
+
+With overwrite, the default, writes every file. With skip, writes only
+files that do not exist. With strict, writes each file only if it has
+not been modified since the rev recorded in the dump.
+
+Stops at the first file that cannot be written. Prints the number of
+files written on stdout.
+`
+}
+
+var restoreModes = map[string]doozer.RestoreMode{
+	"overwrite": doozer.RestoreOverwrite,
+	"skip":      doozer.RestoreSkipExisting,
+	"strict":    doozer.RestoreStrict,
+}
+
+func restore(args ...string) {
+	mode := doozer.RestoreOverwrite
+	if len(args) > 1 {
+		bail(errors.New("too many arguments"))
+	}
+	if len(args) == 1 {
+		m, ok := restoreModes[args[0]]
+		if !ok {
+			bail(errors.New("unknown mode: " + args[0]))
+		}
+		mode = m
+	}
+
+	c := dial()
+
+	n, err := doozer.Restore(c, os.Stdin, mode)
+	fmt.Println(n)
+	if err != nil {
+		bail(err)
+	}
+}
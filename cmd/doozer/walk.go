@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/dcjones/doozer/format"
+)
+
+func init() {
+	cmds["walk"] = cmd{walk, "<glob>", "read many files"}
+	cmdHelp["walk"] = `Prints every file matching <glob>.
+
+If flag -r is given, reads the files as of <rev>.
+
+Output is a sequence of records, one for each file, in the format
+printed by watch. Flags -f and -t select another output format.
+`
+}
+
+func walk(glob string) {
+	c := dial()
+
+	evs, err := c.Walk(glob, storeRev(c), 0, -1)
+	if err != nil {
+		bail(err)
+	}
+
+	f := formatter()
+	for _, ev := range evs {
+		f.Write(format.FromEvent(ev))
+	}
+	f.Flush()
+}
@@ -1,8 +1,7 @@
 package main
 
 import (
-	"fmt"
-	"os"
+	"github.com/dcjones/doozer/format"
 )
 
 func init() {
@@ -23,6 +22,8 @@ Output is a sequence of records, one for each change. Format of each record:
 
 Here, <path> is the file's path, <rev> is the revision of the change,
 <len> is the number of bytes in the body, and LF is an ASCII line-feed char.
+
+Flags -f and -t select another output format.
 `
 }
 
@@ -37,6 +38,7 @@ func watch(glob string) {
 		}
 	}
 
+	f := formatter()
 	for {
 		ev, err := c.Wait(glob, *rrev)
 		if err != nil {
@@ -44,15 +46,7 @@ func watch(glob string) {
 		}
 		*rrev = ev.Rev + 1
 
-		var sd string
-		switch {
-		case ev.IsSet():
-			sd = "set"
-		case ev.IsDel():
-			sd = "del"
-		}
-		fmt.Println(ev.Path, ev.Rev, sd, len(ev.Body))
-		os.Stdout.Write(ev.Body)
-		fmt.Println()
+		f.Write(format.FromEvent(ev))
+		f.Flush()
 	}
 }
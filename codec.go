@@ -0,0 +1,147 @@
+package doozer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"reflect"
+)
+
+// A Codec converts values to and from file bodies.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(body []byte, v interface{}) error
+}
+
+// The codecs provided. Protobuf handles only protocol buffer
+// messages, generated for either the current or the older Go API.
+var (
+	JSON     Codec = jsonCodec{}
+	Gob      Codec = gobCodec{}
+	Protobuf Codec = protoCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(body []byte, v interface{}) error {
+	return json.Unmarshal(body, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(body []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(body)).Decode(v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := protoMessage(v)
+	if !ok {
+		return nil, ErrNotMessage
+	}
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(body []byte, v interface{}) error {
+	m, ok := protoMessage(v)
+	if !ok {
+		return ErrNotMessage
+	}
+	return proto.Unmarshal(body, m)
+}
+
+// protoMessage returns v as a message of the current API,
+// adapting one generated for the older API.
+func protoMessage(v interface{}) (proto.Message, bool) {
+	switch m := v.(type) {
+	case proto.Message:
+		return m, true
+	case protoadapt.MessageV1:
+		return protoadapt.MessageV2Of(m), true
+	}
+	return nil, false
+}
+
+// codecOf returns cd, or, if it is nil, the codec configured for c.
+func codecOf(c Client, cd Codec) Codec {
+	if cd != nil {
+		return cd
+	}
+	if c, ok := c.(*Conn); ok {
+		return c.cfg.codec()
+	}
+	return JSON
+}
+
+// GetValue reads the file at path, as of rev, as Get does, and
+// unmarshals its body into v with cd. If cd is nil, the connection's
+// codec is used. If the file is missing, v is left as it was and the
+// returned revision is 0.
+func GetValue(c Client, cd Codec, path string, rev *int64, v interface{}) (frev int64, err error) {
+	body, frev, err := c.Get(path, rev)
+	if err != nil {
+		return 0, err
+	}
+	if frev == missing {
+		return missing, nil
+	}
+	if err := codecOf(c, cd).Unmarshal(body, v); err != nil {
+		return 0, &Error{err, path}
+	}
+	return frev, nil
+}
+
+// SetValue marshals v with cd and writes it to the file at path, as
+// Set does. If cd is nil, the connection's codec is used.
+func SetValue(c Client, cd Codec, path string, oldRev int64, v interface{}) (newRev int64, err error) {
+	body, err := codecOf(c, cd).Marshal(v)
+	if err != nil {
+		return 0, &Error{err, path}
+	}
+	return c.Set(path, oldRev, body)
+}
+
+// UpdateValue is Update for a file holding a value encoded with cd,
+// or, if cd is nil, the connection's codec. On each attempt, the
+// value v points to is zeroed and then unmarshaled from the file, if
+// it is not missing or empty; fn is called to modify it; and it is
+// marshaled and written back, on the condition that the file has not
+// changed.
+func UpdateValue(c Client, cd Codec, path string, v interface{}, fn func() error) (rev int64, err error) {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return 0, &Error{ErrNotPointer, path}
+	}
+	cd = codecOf(c, cd)
+	return Update(c, path, func(old []byte) ([]byte, error) {
+		p.Elem().Set(reflect.Zero(p.Elem().Type()))
+		if len(old) > 0 {
+			if err := cd.Unmarshal(old, v); err != nil {
+				return nil, &Error{err, path}
+			}
+		}
+		if err := fn(); err != nil {
+			return nil, err
+		}
+		body, err := cd.Marshal(v)
+		if err != nil {
+			return nil, &Error{err, path}
+		}
+		return body, nil
+	})
+}
@@ -0,0 +1,68 @@
+package doozer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+)
+
+// compressHeader begins each body written compressed by Compress.
+// The NUL byte keeps it from being mistaken for the start of text.
+const compressHeader = "\x00dz\x01gz"
+
+// Compress returns an Interceptor that gzips each body of at least
+// threshold bytes written by SET, marking it with a short header, and
+// expands each body so marked in responses, so that callers see only
+// the original bodies. Bodies that would not shrink are written as
+// they are. Sizes reported by Stat are those of the stored bodies.
+//
+// Every client reading the files must use Compress, with any
+// threshold, to see the original bodies.
+func Compress(threshold int) Interceptor {
+	return func(ctx context.Context, req *Request, next Invoker) (*Response, error) {
+		if req.Verb == "SET" && len(req.Value) >= threshold {
+			if body, ok := compress(req.Value); ok {
+				r := *req
+				r.Value = body
+				req = &r
+			}
+		}
+		resp, err := next(ctx, req)
+		if err != nil || !bytes.HasPrefix(resp.Value, []byte(compressHeader)) {
+			return resp, err
+		}
+		body, err := expand(resp.Value)
+		if err != nil {
+			path := resp.Path
+			if path == "" {
+				path = req.Path
+			}
+			return nil, &Error{err, path}
+		}
+		r := *resp
+		r.Value = body
+		return &r, nil
+	}
+}
+
+// compress returns body gzipped behind compressHeader, and whether
+// that is smaller than body.
+func compress(body []byte) ([]byte, bool) {
+	var buf bytes.Buffer
+	buf.WriteString(compressHeader)
+	w := gzip.NewWriter(&buf)
+	w.Write(body)
+	if w.Close() != nil || buf.Len() >= len(body) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+func expand(body []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(body[len(compressHeader):]))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
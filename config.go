@@ -0,0 +1,125 @@
+package doozer
+
+import (
+	"io"
+	"time"
+)
+
+// DefaultMaxMessageSize is the frame size limit
+// used when Config.MaxMessageSize is zero.
+const DefaultMaxMessageSize = 32 << 20
+
+// DefaultParallelism is the limit on concurrent requests
+// used when Config.Parallelism is zero.
+const DefaultParallelism = 32
+
+// A Config holds the options for a connection.
+// The zero value means no timeouts.
+type Config struct {
+	// DialTimeout bounds the time taken to establish the connection.
+	DialTimeout time.Duration
+
+	// ReadTimeout bounds the time taken to receive a frame once it
+	// has started to arrive, and, while any request but a Wait is
+	// outstanding, the time until the next frame begins. A
+	// connection with only Waits outstanding, which may see no
+	// change for a long time, is not timed out.
+	ReadTimeout time.Duration
+
+	// WriteTimeout bounds the time taken to send a frame, or a
+	// batch of frames queued together. A peer that stops accepting
+	// data causes the connection to fail with a timeout error
+	// instead of blocking forever.
+	WriteTimeout time.Duration
+
+	// MaxMessageSize is the largest frame that may be sent or
+	// received. A request that would exceed it fails with
+	// ErrFrameSize; a larger frame from the server closes the
+	// connection. Zero means DefaultMaxMessageSize.
+	MaxMessageSize int
+
+	// Access, if set, supplies the token presented to the server
+	// as soon as the connection is made, and again after Reconnect.
+	Access AccessProvider
+
+	// Interceptors are applied to every request, the first
+	// outermost. See Conn.Use.
+	Interceptors []Interceptor
+
+	// Retry, if set, governs when failed requests are sent again.
+	Retry *RetryPolicy
+
+	// Breaker, if set, fails requests fast while the cluster
+	// is failing. It may be shared by several connections.
+	Breaker *Breaker
+
+	// MaxInFlight, if positive, limits the number of requests
+	// awaiting a response. A request beyond the limit waits for
+	// another to finish, or, if FailBusy is set, fails at once
+	// with ErrBusy. A Wait holds its place until it returns.
+	MaxInFlight int
+	FailBusy    bool
+
+	// Parallelism bounds the requests sent at once by calls
+	// that make many, such as Getdir, GetTree, and SetMulti.
+	// Zero means DefaultParallelism.
+	Parallelism int
+
+	// Resync, if set, recovers a Wait or Walk at a revision the
+	// server has discarded, instead of failing with ErrTooLate.
+	// Walk reads at the current revision instead. Wait returns
+	// the earliest file matching its glob, as of the current
+	// revision, that changed on or after the revision asked for.
+	// Later Waits on the same glob return the rest of those files
+	// in order of revision, from the same read, before waiting
+	// past the current revision. Deletions in the discarded
+	// history are not reported.
+	Resync bool
+
+	// Probe, if set, asks the server on connect which requests it
+	// supports and which version it is. See Conn.Probe.
+	Probe bool
+
+	// Logger, if set, receives warnings about unexpected
+	// responses. If nil, they are discarded.
+	Logger Logger
+
+	// Trace, if set, receives a description of every frame sent
+	// and received, with its decoded message, for debugging.
+	// Frames from one connection are written one at a time.
+	Trace io.Writer
+
+	// Capture, if set, receives a record of every frame sent and
+	// received, to be replayed later with NewReplay.
+	Capture io.Writer
+
+	// Contracts declare the consistency required of reads
+	// beneath path prefixes.
+	Contracts Contracts
+
+	// Codec converts values to and from file bodies for GetValue,
+	// SetValue, and UpdateValue when they are given none.
+	// If nil, JSON is used.
+	Codec Codec
+}
+
+func (cfg *Config) maxMessageSize() int {
+	if cfg.MaxMessageSize > 0 {
+		return cfg.MaxMessageSize
+	}
+	return DefaultMaxMessageSize
+}
+
+func (cfg *Config) codec() Codec {
+	if cfg.Codec != nil {
+		return cfg.Codec
+	}
+	return JSON
+}
+
+func (cfg *Config) parallelism() int {
+	if cfg.Parallelism > 0 {
+		return cfg.Parallelism
+	}
+	return DefaultParallelism
+}
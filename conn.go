@@ -1,16 +1,20 @@
 package doozer
 
 import (
-	"code.google.com/p/goprotobuf/proto"
+	"bufio"
+	"context"
 	"encoding/binary"
 	"errors"
-	"github.com/kr/pretty"
+	"fmt"
+	"google.golang.org/protobuf/proto"
 	"io"
-	"log"
 	"math/rand"
 	"net"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,8 +24,19 @@ var (
 
 var (
 	ErrInvalidUri = errors.New("invalid uri")
+
+	// errReplaced fails requests outstanding on a link that relink
+	// replaced, leaving them to be retried on the new one.
+	errReplaced = errors.New("connection replaced")
 )
 
+// tagSpace is the number of distinct tags: the non-negative int32s.
+const tagSpace = 1 << 31
+
+// outQueue is the number of frames that may wait to be written
+// on a link before callers must wait to send more.
+const outQueue = 64
+
 type txn struct {
 	req  request
 	resp *response
@@ -29,104 +44,279 @@ type txn struct {
 	done chan bool
 }
 
+// reset prepares t to be sent again.
+func (t *txn) reset() {
+	t.req.Tag = nil
+	t.resp = nil
+	t.err = nil
+}
+
 type Conn struct {
-	addr    string
+	addr   string
+	cfg    Config
+	reads  group
+	reauth group
+	stats  stats
+	slots  chan bool // one per request in flight, if limited
+
+	mu     sync.Mutex
+	l      *link
+	access AccessProvider // last provider accepted by Access
+	feat   features       // what the server is known to support
+	closed bool
+
+	resyncs map[string]*resynced // glob -> files read by its last resync
+
+	pending sync.WaitGroup // requests being sent or awaiting a response
+
+	interceptors []Interceptor
+}
+
+// A link is a single network connection to a server
+// and the goroutines that service it.
+type link struct {
+	inUse   int64 // number of tags registered; first, for 64-bit alignment
+	reqs    int64 // number of them awaiting a response, but for WAITs
 	conn    net.Conn
-	send    chan *txn
-	msg     chan []byte
+	cfg     *Config
+	stats   *stats
+	tag     uint32       // last tag handed out
+	tags    uint32       // number of distinct tags, tagSpace but in tests
+	txns    sync.Map     // tag -> *txn awaiting a response
+	out     chan *[]byte // frames waiting to be written
+	once    sync.Once
 	err     error
-	stop    chan bool
 	stopped chan bool
-	timeout time.Duration
+	tmu     sync.Mutex // serializes trace and capture output
+	dmu     sync.Mutex // serializes changes to the read deadline
 }
 
 // Dial connects to a single doozer server.
 func Dial(addr string) (*Conn, error) {
-	return DialTimeout(addr, 0)
+	return DialConfig(addr, nil)
 }
 
+// DialTimeout is like Dial, but uses timeout to bound
+// dialing as well as each read and write on the connection.
 func DialTimeout(addr string, timeout time.Duration) (*Conn, error) {
+	return DialConfig(addr, &Config{
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
+}
+
+// DialConfig connects to a single doozer server using the options
+// in cfg. A nil cfg is the same as the zero Config.
+func DialConfig(addr string, cfg *Config) (*Conn, error) {
 	var c Conn
 	var err error
 	c.addr = addr
-	if timeout > 0 {
-		c.conn, err = net.DialTimeout("tcp", addr, timeout)
+	c.configure(cfg)
+	c.l, err = c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cfg.Access != nil {
+		err = c.AccessWith(context.Background(), c.cfg.Access)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	if c.cfg.Probe {
+		err = c.Probe(context.Background())
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// NewConn returns a Conn that talks to a doozer server over nc,
+// using the options in cfg. A nil cfg is the same as the zero Config.
+// Reconnect dials nc's remote address.
+func NewConn(nc net.Conn, cfg *Config) *Conn {
+	var c Conn
+	c.addr = nc.RemoteAddr().String()
+	c.configure(cfg)
+	c.l = newLink(nc, &c.cfg, &c.stats)
+	return &c
+}
+
+// configure sets c's options from cfg, which may be nil.
+func (c *Conn) configure(cfg *Config) {
+	if cfg != nil {
+		c.cfg = *cfg
+	}
+	c.interceptors = c.cfg.Interceptors
+	if c.cfg.MaxInFlight > 0 {
+		c.slots = make(chan bool, c.cfg.MaxInFlight)
+	}
+}
+
+func (c *Conn) dial() (*link, error) {
+	var nc net.Conn
+	var err error
+	if c.cfg.DialTimeout > 0 {
+		nc, err = net.DialTimeout("tcp", c.addr, c.cfg.DialTimeout)
 	} else {
-		c.conn, err = net.Dial("tcp", addr)
+		nc, err = net.Dial("tcp", c.addr)
 	}
 	if err != nil {
 		return nil, err
 	}
+	return newLink(nc, &c.cfg, &c.stats), nil
+}
 
-	c.send = make(chan *txn)
-	c.msg = make(chan []byte)
-	c.stop = make(chan bool, 1)
-	c.stopped = make(chan bool)
-	c.timeout = timeout
-	errch := make(chan error, 1)
-	go c.mux(errch)
-	go c.readAll(errch)
-	return &c, nil
+func newLink(nc net.Conn, cfg *Config, st *stats) *link {
+	var l link
+	l.conn = nc
+	l.cfg = cfg
+	l.stats = st
+	l.tags = tagSpace
+	l.stopped = make(chan bool)
+	l.out = make(chan *[]byte, outQueue)
+	go l.readAll()
+	go l.writeAll()
+	return &l
 }
 
-// DialUri connects to one of the doozer servers given in `uri`. If `uri`
-// contains a cluster name, it will lookup addrs to try in `buri`.  If `uri`
-// contains a  secret key, then DialUri will call `Access` with the secret.
+// Reconnect replaces c's network connection with a new one to the
+// same server. Requests outstanding on the old connection fail, or
+// are sent again on the new one as c's RetryPolicy allows.
+// If c has authenticated with Access, it authenticates again on the
+// new connection before any other request is sent there.
+func (c *Conn) Reconnect() error {
+	return c.relink(nil)
+}
+
+// relink replaces c's link with a new one, unless old is not nil
+// and c's link is no longer old, because it was already replaced.
+func (c *Conn) relink(old *link) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if old != nil && c.l != old {
+		return nil
+	}
+
+	l, err := c.dial()
+	if err != nil {
+		return err
+	}
+
+	if c.access != nil {
+		err = authenticate(context.Background(), l, c.access)
+		if err != nil {
+			l.close()
+			return err
+		}
+	}
+
+	c.l.fail(errReplaced)
+	c.l = l
+	c.feat = features{} // perhaps a different server now
+	c.stats.reconnected()
+	return nil
+}
+
+// DialUriTimeout is like DialUri, but uses timeout as DialTimeout does.
 func DialUriTimeout(uri, buri string, timeout time.Duration) (*Conn, error) {
+	return DialUriConfig(uri, buri, &Config{
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
+}
+
+// DialUriConfig is like DialUri, but uses the options in cfg
+// for each connection it makes.
+func DialUriConfig(uri, buri string, cfg *Config) (*Conn, error) {
+	addrs, p, err := resolveUri(uri, buri, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := DialConfig(addrs[rand.Int()%len(addrs)], cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, ok := p["sk"]
+	if ok {
+		err = c.Access(secret[0])
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// resolveUri returns the addresses of the servers given in uri,
+// looking them up in buri if necessary, and the parsed query of uri.
+func resolveUri(uri, buri string, cfg *Config) ([]string, url.Values, error) {
 	if !strings.HasPrefix(uri, uriPrefix) {
-		return nil, ErrInvalidUri
+		return nil, nil, ErrInvalidUri
 	}
 
 	q := uri[len(uriPrefix):]
 	p, err := url.ParseQuery(q)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	addrs := make([]string, 0)
 
 	name, ok := p["cn"]
 	if ok && buri != "" {
-		c, err := DialUriTimeout(buri, "", timeout)
+		// The boot uri carries its own secret, if any.
+		var bcfg Config
+		if cfg != nil {
+			bcfg = *cfg
+			bcfg.Access = nil
+		}
+		c, err := DialUriConfig(buri, "", &bcfg)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		defer c.Close()
 
 		addrs, err = lookup(c, name[0])
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	} else {
 		var ok bool
 		addrs, ok = p["ca"]
 		if !ok {
-			return nil, ErrInvalidUri
+			return nil, nil, ErrInvalidUri
 		}
 	}
 
-	c, err := DialTimeout(addrs[rand.Int()%len(addrs)], timeout)
-	if err != nil {
-		return nil, err
-	}
-
-	secret, ok := p["sk"]
-	if ok {
-		err = c.Access(secret[0])
-		if err != nil {
-			c.Close()
-			return nil, err
-		}
+	if len(addrs) == 0 {
+		return nil, nil, ErrNoAddrs
 	}
-
-	return c, nil
+	return addrs, p, nil
 }
 
+// DialUri connects to one of the doozer servers given in `uri`. If `uri`
+// contains a cluster name, it will lookup addrs to try in `buri`.  If `uri`
+// contains a  secret key, then DialUri will call `Access` with the secret.
 func DialUri(uri, buri string) (*Conn, error) {
-	return DialUriTimeout(uri, buri, 0)
+	return DialUriConfig(uri, buri, nil)
 }
 
 // Find possible addresses for cluster named name.
-func lookup(b *Conn, name string) (as []string, err error) {
+func lookup(b Client, name string) (as []string, err error) {
 	rev, err := b.Rev()
 	if err != nil {
 		return nil, err
@@ -152,163 +342,479 @@ func lookup(b *Conn, name string) (as []string, err error) {
 }
 
 func (c *Conn) call(t *txn) error {
-	t.done = make(chan bool)
-	select {
-	case <-c.stopped:
-		return c.err
-	case c.send <- t:
-		<-t.done
-		if t.err != nil {
-			return t.err
+	return c.callContext(context.Background(), t)
+}
+
+// callContext is like call, but gives up waiting for
+// the response when ctx is done.
+func (c *Conn) callContext(ctx context.Context, t *txn) (err error) {
+	c.mu.Lock()
+	is := c.interceptors
+	c.mu.Unlock()
+
+	verb := *t.req.Verb
+	c.stats.start(verb)
+	defer func(start time.Time) {
+		c.stats.observe(verb, time.Since(start), err)
+	}(time.Now())
+
+	if len(is) == 0 {
+		return c.retry(ctx, t)
+	}
+	return c.intercept(ctx, t, is)
+}
+
+// roundTrip sends t on the current link and waits for the response.
+func (c *Conn) roundTrip(ctx context.Context, t *txn) (err error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.pending.Add(1)
+	c.mu.Unlock()
+	defer c.pending.Done()
+
+	if c.slots != nil {
+		err = c.acquire(ctx)
+		if err != nil {
+			return err
 		}
-		if t.resp.ErrCode != nil {
-			return newError(t)
+		defer func() { <-c.slots }()
+	}
+
+	verb := *t.req.Verb
+	if b := c.cfg.Breaker; b != nil {
+		if verb == request_WAIT {
+			// A Wait may rightly block for a long time, so it
+			// says nothing about the cluster and can't be a probe.
+			if b.Open() {
+				return ErrUnavailable
+			}
+		} else {
+			if !b.allow() {
+				return ErrUnavailable
+			}
+			defer func() { b.record(err) }()
 		}
 	}
-	return nil
+
+	if c.refused(verb) {
+		return &Error{ErrUnsupported, request_Verb_name[int32(verb)]}
+	}
+
+	c.mu.Lock()
+	l, p := c.l, c.access
+	c.mu.Unlock()
+
+	defer func() {
+		if isUnknownVerb(err) {
+			c.noteVerb(verb, false)
+			err = &Error{ErrUnsupported, request_Verb_name[int32(verb)]}
+		}
+	}()
+
+	err = l.call(ctx, t)
+	if p == nil || !isAccessErr(err) || verb == request_ACCESS {
+		return err
+	}
+
+	// The server has forgotten us, perhaps because it restarted.
+	// Authenticate again and replay the request, once.
+	_, _, aerr := c.reauth.do(fmt.Sprintf("%p", l), func() (interface{}, error) {
+		return nil, authenticate(ctx, l, p)
+	})
+	if aerr != nil {
+		return err
+	}
+	t.reset()
+	return l.call(ctx, t)
 }
 
-// After Close is called, operations on c will return ErrClosed.
-func (c *Conn) Close() {
+// acquire takes one of c's slots for a request in flight, waiting
+// for one to be free unless c's config says to fail with ErrBusy.
+func (c *Conn) acquire(ctx context.Context) error {
+	if c.cfg.FailBusy {
+		select {
+		case c.slots <- true:
+			return nil
+		default:
+			return ErrBusy
+		}
+	}
+
 	select {
-	case c.stop <- true:
-	default:
+	case c.slots <- true:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-func (c *Conn) mux(errch chan error) {
-	txns := make(map[int32]*txn)
-	var n int32 // next tag
-	var err error
+func isAccessErr(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Err == ErrAccess
+}
+
+func (l *link) call(ctx context.Context, t *txn) error {
+	// The reader never waits for a caller that has given up.
+	t.done = make(chan bool, 1)
+
+	tag, err := l.register(t)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-l.stopped:
+		return l.abandon(tag)
+	default:
+	}
 
+	pb, err := marshal(&t.req)
+	if err != nil {
+		l.release(tag)
+		return err
+	}
+	if len(*pb) > l.cfg.maxMessageSize() {
+		l.release(tag)
+		putMarshal(pb)
+		return ErrFrameSize
+	}
+
+	select {
+	case l.out <- pb:
+	case <-l.stopped:
+		return l.abandon(tag)
+	case <-ctx.Done():
+		// Not sent, so no response will come for tag.
+		l.release(tag)
+		return ctx.Err()
+	}
+
+	// Once sent, t stays registered until its response arrives,
+	// or the server confirms it is cancelled, so that its tag is
+	// not reused for another request meanwhile.
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		if *t.req.Verb != request_CANCEL {
+			go l.cancel(tag, t)
+		}
+		return ctx.Err()
+	}
+	if t.err != nil {
+		return t.err
+	}
+	if t.resp.ErrCode != nil {
+		return newError(t)
+	}
+	return nil
+}
+
+// register gives t an unused tag and records it as awaiting
+// a response. Tags are handed out in turn, wrapping around after
+// the largest; a tag still in use, such as one held by a long-lived
+// Wait, is skipped. If every tag is in use, register fails with
+// ErrNoTags.
+func (l *link) register(t *txn) (int32, error) {
+	if atomic.AddInt64(&l.inUse, 1) > int64(l.tags) {
+		atomic.AddInt64(&l.inUse, -1)
+		return 0, ErrNoTags
+	}
 	for {
-		select {
-		case t := <-c.send:
-			// find an unused tag
-			for t := txns[n]; t != nil; t = txns[n] {
-				n++
+		tag := int32(atomic.AddUint32(&l.tag, 1) % l.tags)
+		t.req.Tag = &tag
+		if _, used := l.txns.LoadOrStore(tag, t); !used {
+			if *t.req.Verb != request_WAIT {
+				atomic.AddInt64(&l.reqs, 1)
+				l.deadline()
 			}
-			txns[n] = t
+			return tag, nil
+		}
+	}
+}
 
-			// don't take n's address; it will change
-			tag := n
-			t.req.Tag = &tag
+// deadline sets the read deadline for the next frame: ReadTimeout
+// from now if a response other than a WAIT's is awaited, or none, as
+// the server may legitimately say nothing for a long time otherwise.
+func (l *link) deadline() {
+	if l.cfg.ReadTimeout <= 0 {
+		return
+	}
+	l.dmu.Lock()
+	defer l.dmu.Unlock()
+	if atomic.LoadInt64(&l.reqs) > 0 {
+		l.conn.SetReadDeadline(time.Now().Add(l.cfg.ReadTimeout))
+	} else {
+		l.conn.SetReadDeadline(time.Time{})
+	}
+}
 
-			var buf []byte
-			buf, err = proto.Marshal(&t.req)
-			if err != nil {
-				txns[n] = nil
+// forget records that t no longer awaits a response.
+func (l *link) forget(t *txn) {
+	atomic.AddInt64(&l.inUse, -1)
+	if *t.req.Verb != request_WAIT {
+		atomic.AddInt64(&l.reqs, -1)
+	}
+}
+
+// release removes and returns the txn registered with tag, if any,
+// freeing the tag.
+func (l *link) release(tag int32) (*txn, bool) {
+	v, ok := l.txns.LoadAndDelete(tag)
+	if !ok {
+		return nil, false
+	}
+	t := v.(*txn)
+	l.forget(t)
+	return t, true
+}
+
+// cancel asks the server to stop work on t, registered with tag,
+// which its caller has given up on. Once the server confirms it,
+// no response will come for tag, so it is freed. A server that does
+// not know CANCEL says so, and t waits for its response as before.
+func (l *link) cancel(tag int32, t *txn) {
+	var c txn
+	c.req.Verb = request_CANCEL.Enum()
+	c.req.OtherTag = &tag
+
+	err := l.call(context.Background(), &c)
+	if err != nil {
+		return
+	}
+	if l.txns.CompareAndDelete(tag, t) {
+		l.forget(t)
+	}
+}
+
+// abandon removes the txn registered with tag after l has failed,
+// and returns l's error. If fail got to the txn first, it has
+// already been given the error.
+func (l *link) abandon(tag int32) error {
+	l.release(tag)
+	return l.err
+}
+
+// After Close is called, operations on c will return ErrClosed.
+func (c *Conn) Close() {
+	c.mu.Lock()
+	c.closed = true
+	c.l.close()
+	c.mu.Unlock()
+}
+
+// CloseGracefully stops c from taking new requests, as Close does,
+// but lets those already made finish before closing the connection.
+// If ctx is done first, the remaining requests fail with ErrClosed
+// and CloseGracefully returns ctx's error. A pending Wait counts as
+// unfinished until it sees an event, so ctx should bound the time
+// spent waiting.
+func (c *Conn) CloseGracefully(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan bool)
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	c.l.close()
+	c.mu.Unlock()
+	return err
+}
+
+func (l *link) close() {
+	l.fail(ErrClosed)
+}
+
+// fail shuts l down, the first time it is called, giving err
+// to every request awaiting a response.
+func (l *link) fail(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.stopped)
+		l.conn.Close()
+		l.txns.Range(func(tag, _ interface{}) bool {
+			if t, ok := l.release(tag.(int32)); ok {
 				t.err = err
 				t.done <- true
-				continue
 			}
+			return true
+		})
+	})
+}
 
-			err = c.write(buf)
-			if err != nil {
-				goto error
+// receive delivers the response in buf to the txn it answers.
+func (l *link) receive(buf []byte) {
+	l.capture(captureResponse, buf)
+	var r response
+	err := proto.Unmarshal(buf, &r)
+	if err != nil {
+		l.trace("<-", buf)
+		l.cfg.logger().Warn("doozer: bad response", "err", err)
+		return
+	}
+	l.trace("<-", buf)
+
+	if r.Tag == nil {
+		l.cfg.logger().Warn("doozer: response without tag",
+			"response", r.String())
+		return
+	}
+	t, ok := l.release(*r.Tag)
+	if !ok {
+		l.cfg.logger().Warn("doozer: unexpected response",
+			"tag", *r.Tag, "response", r.String())
+		return
+	}
+
+	t.resp = &r
+	t.done <- true
+}
+
+// writeAll sends the frames queued by callers, so that a slow
+// peer holds up only the writes, not delivery of responses.
+// Frames queued while it is busy are sent together.
+func (l *link) writeAll() {
+	w := bufio.NewWriter(l.conn)
+	for {
+		select {
+		case pb := <-l.out:
+			if l.cfg.WriteTimeout > 0 {
+				l.conn.SetWriteDeadline(time.Now().Add(l.cfg.WriteTimeout))
 			}
-		case buf := <-c.msg:
-			var r response
-			err = proto.Unmarshal(buf, &r)
-			if err != nil {
-				log.Print(err)
-				continue
+			err := l.write(w, pb)
+		more:
+			for err == nil {
+				select {
+				case pb = <-l.out:
+					err = l.write(w, pb)
+				default:
+					break more
+				}
 			}
-
-			if r.Tag == nil {
-				log.Printf("nil tag: %# v", pretty.Formatter(r))
-				continue
+			if err == nil {
+				err = w.Flush()
 			}
-			t := txns[*r.Tag]
-			if t == nil {
-				log.Printf("unexpected: %# v", pretty.Formatter(r))
-				continue
+			if err != nil {
+				l.fail(err)
+				return
 			}
-
-			delete(txns, *r.Tag)
-			t.resp = &r
-			t.done <- true
-		case err = <-errch:
-			goto error
-		case <-c.stop:
-			err = ErrClosed
-			goto error
+		case <-l.stopped:
+			return
 		}
 	}
-
-error:
-	c.err = err
-	for _, t := range txns {
-		t.err = err
-		t.done <- true
-	}
-	c.conn.Close()
-	close(c.stopped)
 }
 
-func (c *Conn) readAll(errch chan error) {
+func (l *link) readAll() {
 	for {
-		buf, err := c.read()
+		p, err := l.read()
 		if err != nil {
-			errch <- err
+			l.fail(err)
 			return
 		}
-
-		c.msg <- buf
+		// The response does not refer to the frame once decoded.
+		l.receive(*p)
+		putRead(p)
 	}
 }
 
-func (c *Conn) read() ([]byte, error) {
-	if c.timeout > 0 {
-		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+// read returns the next frame, in a buffer from readPool.
+func (l *link) read() (*[]byte, error) {
+	var hdr [4]byte
+
+	l.deadline()
+	_, err := io.ReadFull(l.conn, hdr[:1])
+	if err != nil {
+		return nil, err
 	}
 
-	var size int32
-	err := binary.Read(c.conn, binary.BigEndian, &size)
+	if l.cfg.ReadTimeout > 0 {
+		l.conn.SetReadDeadline(time.Now().Add(l.cfg.ReadTimeout))
+	}
+	_, err = io.ReadFull(l.conn, hdr[1:])
 	if err != nil {
 		return nil, err
 	}
 
-	buf := make([]byte, size)
-	_, err = io.ReadFull(c.conn, buf)
+	size := int32(binary.BigEndian.Uint32(hdr[:]))
+	if size < 0 || int64(size) > int64(l.cfg.maxMessageSize()) {
+		// Don't trust the rest of the stream.
+		return nil, ErrFrameSize
+	}
+	p := getRead(int(size))
+	_, err = io.ReadFull(l.conn, *p)
 	if err != nil {
+		putRead(p)
 		return nil, err
 	}
 
-	return buf, nil
+	l.stats.frame(len(*p), false)
+	return p, nil
 }
 
-func (c *Conn) write(buf []byte) error {
-	if c.timeout > 0 {
-		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+// write adds the frame in pb to w, and returns pb to the pool.
+func (l *link) write(w *bufio.Writer, pb *[]byte) error {
+	defer putMarshal(pb)
+	buf := *pb
+	l.trace("->", buf)
+	l.capture(captureRequest, buf)
+
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(buf)))
+	_, err := w.Write(hdr[:])
+	if err != nil {
+		return err
 	}
 
-	err := binary.Write(c.conn, binary.BigEndian, int32(len(buf)))
+	_, err = w.Write(buf)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.conn.Write(buf)
-	return err
+	l.stats.frame(len(buf), true)
+	return nil
 }
 
-// Attempts access to the store
+// Attempts access to the store.
+// The token is remembered and presented again by Reconnect.
 func (c *Conn) Access(token string) error {
+	return c.AccessWith(context.Background(), StaticToken(token))
+}
+
+func accessTxn(token string) *txn {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_ACCESS)
+	t.req.Verb = request_ACCESS.Enum()
 	t.req.Value = []byte(token)
-	return c.call(&t)
+	return &t
 }
 
 // Sets the contents of file to body, if it hasn't been modified since oldRev.
 func (c *Conn) Set(file string, oldRev int64, body []byte) (newRev int64, err error) {
+	return c.SetContext(context.Background(), file, oldRev, body)
+}
+
+// SetContext is like Set, but gives up when ctx is done, and passes
+// ctx to c's interceptors.
+func (c *Conn) SetContext(ctx context.Context, file string, oldRev int64, body []byte) (newRev int64, err error) {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_SET)
+	t.req.Verb = request_SET.Enum()
 	t.req.Path = &file
 	t.req.Value = body
 	t.req.Rev = &oldRev
 
-	err = c.call(&t)
+	err = c.callContext(ctx, &t)
 	if err != nil {
 		return
 	}
@@ -318,29 +824,88 @@ func (c *Conn) Set(file string, oldRev int64, body []byte) (newRev int64, err er
 
 // Deletes file, if it hasn't been modified since rev.
 func (c *Conn) Del(file string, rev int64) error {
+	return c.DelContext(context.Background(), file, rev)
+}
+
+// DelContext is like Del, but gives up when ctx is done, and passes
+// ctx to c's interceptors.
+func (c *Conn) DelContext(ctx context.Context, file string, rev int64) error {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_DEL)
+	t.req.Verb = request_DEL.Enum()
 	t.req.Path = &file
 	t.req.Rev = &rev
-	return c.call(&t)
+	return c.callContext(ctx, &t)
 }
 
 func (c *Conn) Nop() error {
+	return c.NopContext(context.Background())
+}
+
+// NopContext is like Nop, but gives up when ctx is done, and passes
+// ctx to c's interceptors.
+func (c *Conn) NopContext(ctx context.Context) error {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_NOP)
-	return c.call(&t)
+	t.req.Verb = request_NOP.Enum()
+	return c.callContext(ctx, &t)
 }
 
 // Returns the body and revision of the file at path,
 // as of store revision *rev.
 // If rev is nil, uses the current state.
+// Concurrent identical calls share a single request to the server,
+// unless c's contracts require linearizable reads of file.
 func (c *Conn) Get(file string, rev *int64) ([]byte, int64, error) {
+	if rev == nil && c.Consistency(file) == Linearizable {
+		return c.get(context.Background(), file, rev)
+	}
+
+	type result struct {
+		body []byte
+		rev  int64
+	}
+	v, shared, err := c.reads.do(flightKey("GET", file, rev), func() (interface{}, error) {
+		body, rev, err := c.get(context.Background(), file, rev)
+		return result{body, rev}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	r := v.(result)
+	if shared && r.body != nil {
+		r.body = append([]byte(nil), r.body...)
+	}
+	return r.body, r.rev, nil
+}
+
+// GetContext is like Get, but gives up when ctx is done, and passes
+// ctx to c's interceptors. It makes its own request, rather than
+// sharing one with concurrent calls, so that only ctx governs it.
+func (c *Conn) GetContext(ctx context.Context, file string, rev *int64) ([]byte, int64, error) {
+	return c.get(ctx, file, rev)
+}
+
+// GetIfChanged is like Get with the current state, but fails with
+// ErrNotModified, without fetching the body, if the file's revision
+// is still lastRev. It first checks the revision with Stat, so
+// polling a large file that seldom changes costs little.
+func (c *Conn) GetIfChanged(file string, lastRev int64) ([]byte, int64, error) {
+	_, rev, err := c.Stat(file, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if rev == lastRev {
+		return nil, rev, &Error{ErrNotModified, file}
+	}
+	return c.Get(file, nil)
+}
+
+func (c *Conn) get(ctx context.Context, file string, rev *int64) ([]byte, int64, error) {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_GET)
+	t.req.Verb = request_GET.Enum()
 	t.req.Path = &file
 	t.req.Rev = rev
 
-	err := c.call(&t)
+	err := c.callContext(ctx, &t)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -351,33 +916,100 @@ func (c *Conn) Get(file string, rev *int64) ([]byte, int64, error) {
 // Getdir reads up to lim names from dir, at revision rev, into an array.
 // Names are read in lexicographical order, starting at position off.
 // A negative lim means to read until the end.
+// Concurrent identical calls share a single listing.
 func (c *Conn) Getdir(dir string, rev int64, off, lim int) (names []string, err error) {
+	v, shared, err := c.reads.do(flightKey("GETDIR", dir, &rev, off, lim), func() (interface{}, error) {
+		return c.getdir(dir, rev, off, lim)
+	})
+	if err != nil {
+		return nil, err
+	}
+	names = v.([]string)
+	if shared && names != nil {
+		names = append([]string(nil), names...)
+	}
+	return names, nil
+}
+
+// getdir learns the number of entries in dir from Stat, and then
+// requests the names in the range wanted all at once, rather than
+// one after another. If dir is not a directory, it falls back to
+// asking for one name at a time, to fail as the server would.
+func (c *Conn) getdir(d string, rev int64, off, lim int) ([]string, error) {
+	n, frev, err := c.stat(context.Background(), d, &rev)
+	if err != nil {
+		return nil, err
+	}
+	if frev != dir {
+		return c.getdirSerial(d, rev, off, lim)
+	}
+
+	if off > n {
+		off = n
+	}
+	if lim < 0 || off+lim > n {
+		lim = n - off
+	}
+	if lim == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, lim)
+	errs := make([]error, lim)
+	c.each(lim, func(i int) {
+		names[i], errs[i] = c.getdirAt(context.Background(), d, rev, off+i)
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+func (c *Conn) getdirSerial(dir string, rev int64, off, lim int) (names []string, err error) {
 	for lim != 0 {
-		var t txn
-		t.req.Verb = newRequest_Verb(request_GETDIR)
-		t.req.Rev = &rev
-		t.req.Path = &dir
-		t.req.Offset = proto.Int32(int32(off))
-		err = c.call(&t)
+		var name string
+		name, err = c.getdirAt(context.Background(), dir, rev, off)
 		if err, ok := err.(*Error); ok && err.Err == ErrRange {
 			return names, nil
 		}
 		if err != nil {
 			return nil, err
 		}
-		names = append(names, *t.resp.Path)
+		names = append(names, name)
 		off++
 		lim--
 	}
 	return
 }
 
+// getdirAt returns the name at position off in dir.
+func (c *Conn) getdirAt(ctx context.Context, dir string, rev int64, off int) (string, error) {
+	var t txn
+	t.req.Verb = request_GETDIR.Enum()
+	t.req.Rev = &rev
+	t.req.Path = &dir
+	t.req.Offset = proto.Int32(int32(off))
+	err := c.callContext(ctx, &t)
+	if err != nil {
+		return "", err
+	}
+	return *t.resp.Path, nil
+}
+
 // Getdirinfo reads metadata for up to lim files from dir, at revision rev,
 // into an array.
 // Files are read in lexicographical order, starting at position off.
 // A negative lim means to read until the end.
 // Getdirinfo returns the array and an error, if any.
 func (c *Conn) Getdirinfo(dir string, rev int64, off, lim int) (a []FileInfo, err error) {
+	return Getdirinfo(c, dir, rev, off, lim)
+}
+
+// Getdirinfo is like Conn.Getdirinfo, reading through any Client.
+func Getdirinfo(c Client, dir string, rev int64, off, lim int) (a []FileInfo, err error) {
 	names, err := c.Getdir(dir, rev, off, lim)
 	if err != nil {
 		return nil, err
@@ -387,22 +1019,44 @@ func (c *Conn) Getdirinfo(dir string, rev int64, off, lim int) (a []FileInfo, er
 		dir += "/"
 	}
 	a = make([]FileInfo, len(names))
+	sem := make(chan bool, parallelismOf(c))
+	var wg sync.WaitGroup
 	for i, name := range names {
-		var fp *FileInfo
-		fp, err = c.Statinfo(rev, dir+name)
-		if err != nil {
-			a[i].Name = name
-		} else {
-			a[i] = *fp
-		}
+		sem <- true
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			fp, err := Statinfo(c, rev, dir+name)
+			if err != nil {
+				a[i].Name = name
+			} else {
+				a[i] = *fp
+			}
+			<-sem
+		}(i, name)
 	}
+	wg.Wait()
 	return
 }
 
+// parallelismOf returns the number of requests that may be sent
+// to c at once. A Client other than a *Conn is sent one at a time.
+func parallelismOf(c Client) int {
+	if c, ok := c.(*Conn); ok {
+		return c.cfg.parallelism()
+	}
+	return 1
+}
+
 // Statinfo returns metadata about the file or directory at path,
 // in revision *storeRev. If storeRev is nil, uses the current
 // revision.
 func (c *Conn) Statinfo(rev int64, path string) (f *FileInfo, err error) {
+	return Statinfo(c, rev, path)
+}
+
+// Statinfo is like Conn.Statinfo, reading through any Client.
+func Statinfo(c Client, rev int64, path string) (f *FileInfo, err error) {
 	f = new(FileInfo)
 	f.Len, f.Rev, err = c.Stat(path, &rev)
 	if err != nil {
@@ -420,13 +1074,41 @@ func (c *Conn) Statinfo(rev int64, path string) (f *FileInfo, err error) {
 // Stat returns metadata about the file or directory at path,
 // in revision *storeRev. If storeRev is nil, uses the current
 // revision.
+// Concurrent identical calls share a single request to the server,
+// unless c's contracts require linearizable reads of path.
 func (c *Conn) Stat(path string, storeRev *int64) (len int, fileRev int64, err error) {
+	if storeRev == nil && c.Consistency(path) == Linearizable {
+		return c.stat(context.Background(), path, storeRev)
+	}
+
+	type result struct {
+		len int
+		rev int64
+	}
+	v, _, err := c.reads.do(flightKey("STAT", path, storeRev), func() (interface{}, error) {
+		len, rev, err := c.stat(context.Background(), path, storeRev)
+		return result{len, rev}, err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	r := v.(result)
+	return r.len, r.rev, nil
+}
+
+// StatContext is like Stat, but gives up when ctx is done, and passes
+// ctx to c's interceptors. Like GetContext, it makes its own request.
+func (c *Conn) StatContext(ctx context.Context, path string, storeRev *int64) (len int, fileRev int64, err error) {
+	return c.stat(ctx, path, storeRev)
+}
+
+func (c *Conn) stat(ctx context.Context, path string, storeRev *int64) (len int, fileRev int64, err error) {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_STAT)
+	t.req.Verb = request_STAT.Enum()
 	t.req.Path = &path
 	t.req.Rev = storeRev
 
-	err = c.call(&t)
+	err = c.callContext(ctx, &t)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -439,39 +1121,227 @@ func (c *Conn) Stat(path string, storeRev *int64) (len int, fileRev int64, err e
 // A negative lim means to read until the end.
 // Conn.Walk will be removed in a future release. Use Walk instead.
 func (c *Conn) Walk(glob string, rev int64, off, lim int) (info []Event, err error) {
+	off0, lim0 := off, lim
 	for lim != 0 {
-		var t txn
-		t.req.Verb = newRequest_Verb(request_WALK)
-		t.req.Rev = &rev
-		t.req.Path = &glob
-		t.req.Offset = proto.Int32(int32(off))
-		err = c.call(&t)
+		var ev Event
+		ev, err = c.walkAt(context.Background(), glob, rev, off)
 		if err, ok := err.(*Error); ok && err.Err == ErrRange {
 			return info, nil
 		}
+		if isTooLate(err) && c.cfg.Resync {
+			rev, err = c.Rev()
+			if err != nil {
+				return nil, err
+			}
+			info, off, lim = nil, off0, lim0
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
-		info = append(info, Event{
-			*t.resp.Rev,
-			*t.resp.Path,
-			t.resp.Value,
-			*t.resp.Flags,
-		})
+		info = append(info, ev)
 		off++
 		lim--
 	}
 	return
 }
 
+// walkAt returns the file at position off among those matching glob.
+func (c *Conn) walkAt(ctx context.Context, glob string, rev int64, off int) (Event, error) {
+	var t txn
+	t.req.Verb = request_WALK.Enum()
+	t.req.Rev = &rev
+	t.req.Path = &glob
+	t.req.Offset = proto.Int32(int32(off))
+	err := c.callContext(ctx, &t)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		*t.resp.Rev,
+		*t.resp.Path,
+		t.resp.Value,
+		*t.resp.Flags,
+	}, nil
+}
+
 // Waits for the first change, on or after rev, to any file matching glob.
 func (c *Conn) Wait(glob string, rev int64) (ev Event, err error) {
+	return c.wait(context.Background(), glob, rev)
+}
+
+// WaitContext is like Wait, but gives up when ctx is done, returning
+// ctx's error. Only this Wait is abandoned; c stays open for others,
+// and the server is asked to stop watching for it.
+func (c *Conn) WaitContext(ctx context.Context, glob string, rev int64) (ev Event, err error) {
+	return c.wait(ctx, glob, rev)
+}
+
+// WaitN collects the next n changes, on or after rev, to files
+// matching glob, in order. If ctx is done first, it returns the
+// changes collected so far along with ctx's error.
+func (c *Conn) WaitN(ctx context.Context, glob string, rev int64, n int) ([]Event, error) {
+	evs := make([]Event, 0, n)
+	for len(evs) < n {
+		ev, err := c.wait(ctx, glob, rev)
+		if err != nil {
+			return evs, err
+		}
+		evs = append(evs, ev)
+		rev = ev.Rev + 1
+	}
+	return evs, nil
+}
+
+// WaitCreated waits until the file at path exists, as of rev or
+// later, and returns the revision of the file's first body seen.
+// If the file already exists at rev, it returns at once.
+func (c *Conn) WaitCreated(path string, rev int64) (int64, error) {
+	_, frev, err := c.Get(path, &rev)
+	if err != nil {
+		return 0, err
+	}
+	if frev != missing {
+		return frev, nil
+	}
+
+	for {
+		ev, err := c.Wait(path, rev+1)
+		if err != nil {
+			return 0, err
+		}
+		if ev.IsSet() {
+			return ev.Rev, nil
+		}
+		rev = ev.Rev
+	}
+}
+
+// WaitDeleted waits until the file at path does not exist, as of
+// rev or later, and returns the revision at which that was seen.
+// If the file is already missing at rev, it returns rev at once.
+func (c *Conn) WaitDeleted(path string, rev int64) (int64, error) {
+	_, frev, err := c.Get(path, &rev)
+	if err != nil {
+		return 0, err
+	}
+	if frev == missing {
+		return rev, nil
+	}
+
+	for {
+		ev, err := c.Wait(path, rev+1)
+		if err != nil {
+			return 0, err
+		}
+		if ev.IsDel() {
+			return ev.Rev, nil
+		}
+		rev = ev.Rev
+	}
+}
+
+// WaitTimeout is like Wait, but gives up if nothing matching glob
+// changes within timeout, returning ErrWaitTimeout.
+func (c *Conn) WaitTimeout(glob string, rev int64, timeout time.Duration) (ev Event, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ev, err = c.wait(ctx, glob, rev)
+	if err == context.DeadlineExceeded {
+		err = ErrWaitTimeout
+	}
+	return ev, err
+}
+
+func (c *Conn) wait(ctx context.Context, glob string, rev int64) (Event, error) {
+	ev, rev, ok := c.buffered(glob, rev)
+	if ok {
+		return ev, nil
+	}
+	ev, err := c.waitOnce(ctx, glob, rev)
+	if isTooLate(err) && c.cfg.Resync {
+		return c.resync(ctx, glob, rev)
+	}
+	return ev, err
+}
+
+// resynced holds the files matching a glob, read at rev by a
+// resync, that changed in history the server had discarded.
+type resynced struct {
+	rev int64
+	evs []Event // in order of revision
+}
+
+// resync reads the files matching glob as of the current revision,
+// keeping those that changed on or after rev for later waits to
+// drain in order, and returns the earliest. If there are none, it
+// waits from the current revision.
+func (c *Conn) resync(ctx context.Context, glob string, rev int64) (Event, error) {
+	cur, err := c.rev(ctx)
+	if err != nil {
+		return Event{}, err
+	}
+
+	r := &resynced{rev: cur}
+	for off := 0; ; off++ {
+		ev, err := c.walkAt(ctx, glob, cur, off)
+		if err, ok := err.(*Error); ok && err.Err == ErrRange {
+			break
+		}
+		if err != nil {
+			return Event{}, err
+		}
+		if ev.Rev >= rev {
+			r.evs = append(r.evs, ev)
+		}
+	}
+	sort.Stable(byRev(r.evs))
+
+	c.mu.Lock()
+	if c.resyncs == nil {
+		c.resyncs = make(map[string]*resynced)
+	}
+	c.resyncs[glob] = r
+	c.mu.Unlock()
+
+	ev, rev, ok := c.buffered(glob, rev)
+	if ok {
+		return ev, nil
+	}
+	return c.waitOnce(ctx, glob, rev)
+}
+
+// buffered returns the earliest file read by the last resync of glob
+// that changed on or after rev. If there is none, it returns the
+// revision to wait from instead: past the resync, if rev was within
+// it, or else rev. A wait past the resync drops its files.
+func (c *Conn) buffered(glob string, rev int64) (Event, int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := c.resyncs[glob]
+	if r == nil {
+		return Event{}, rev, false
+	}
+	if rev > r.rev {
+		delete(c.resyncs, glob)
+		return Event{}, rev, false
+	}
+	i := sort.Search(len(r.evs), func(i int) bool { return r.evs[i].Rev >= rev })
+	if i < len(r.evs) {
+		return r.evs[i], rev, true
+	}
+	return Event{}, r.rev + 1, false
+}
+
+// waitOnce sends a single WAIT, leaving ErrTooLate to the caller.
+func (c *Conn) waitOnce(ctx context.Context, glob string, rev int64) (ev Event, err error) {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_WAIT)
+	t.req.Verb = request_WAIT.Enum()
 	t.req.Path = &glob
 	t.req.Rev = &rev
 
-	err = c.call(&t)
+	err = c.callContext(ctx, &t)
 	if err != nil {
 		return
 	}
@@ -485,10 +1355,20 @@ func (c *Conn) Wait(glob string, rev int64) (ev Event, err error) {
 
 // Rev returns the current revision of the store.
 func (c *Conn) Rev() (int64, error) {
+	return c.rev(context.Background())
+}
+
+// RevContext is like Rev, but gives up when ctx is done, and passes
+// ctx to c's interceptors.
+func (c *Conn) RevContext(ctx context.Context) (int64, error) {
+	return c.rev(ctx)
+}
+
+func (c *Conn) rev(ctx context.Context) (int64, error) {
 	var t txn
-	t.req.Verb = newRequest_Verb(request_REV)
+	t.req.Verb = request_REV.Enum()
 
-	err := c.call(&t)
+	err := c.callContext(ctx, &t)
 	if err != nil {
 		return 0, err
 	}
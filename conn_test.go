@@ -0,0 +1,155 @@
+package doozer
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// newWait returns a txn for a WAIT, which holds its tag for as long
+// as it takes.
+func newWait() *txn {
+	t := new(txn)
+	t.req.Verb = request_WAIT.Enum()
+	return t
+}
+
+func mustRegister(t *testing.T, l *link) int32 {
+	t.Helper()
+	tag, err := l.register(newWait())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tag
+}
+
+func TestTagWrap(t *testing.T) {
+	l := &link{tags: 4}
+	want := []int32{1, 2, 3, 0, 1, 2}
+	for i, w := range want {
+		tag := mustRegister(t, l)
+		if tag != w {
+			t.Fatalf("request %d: tag %d, want %d", i, tag, w)
+		}
+		l.release(tag)
+	}
+
+	// The counter itself wraps after billions of requests.
+	l = &link{tags: tagSpace, tag: math.MaxUint32 - 1}
+	for _, w := range []int32{math.MaxInt32, 0, 1} {
+		tag := mustRegister(t, l)
+		if tag != w {
+			t.Fatalf("tag %d, want %d", tag, w)
+		}
+		l.release(tag)
+	}
+}
+
+func TestTagSkipsInUse(t *testing.T) {
+	l := &link{tags: 4}
+	held := mustRegister(t, l) // a long-lived Wait
+
+	var got []int32
+	for i := 0; i < 6; i++ {
+		tag := mustRegister(t, l)
+		if tag == held {
+			t.Fatalf("tag %d handed out while in use", tag)
+		}
+		got = append(got, tag)
+		l.release(tag)
+	}
+	want := []int32{2, 3, 0, 2, 3, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tags %v, want %v", got, want)
+		}
+	}
+}
+
+func TestNoTags(t *testing.T) {
+	l := &link{tags: 4}
+	var tags []int32
+	for i := 0; i < 4; i++ {
+		tags = append(tags, mustRegister(t, l))
+	}
+	if _, err := l.register(newWait()); err != ErrNoTags {
+		t.Fatalf("err %v, want ErrNoTags", err)
+	}
+
+	l.release(tags[2])
+	if tag := mustRegister(t, l); tag != tags[2] {
+		t.Fatalf("tag %d, want freed tag %d", tag, tags[2])
+	}
+}
+
+// scriptConn returns a Conn with the given config whose requests
+// are answered by s.
+func scriptConn(s *Script, cfg *Config) *Conn {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return NewConn(client, cfg)
+}
+
+func TestReadTimeout(t *testing.T) {
+	s := NewScript()
+	s.On("GET", "/a", Reply{Body: []byte("x"), Rev: 1})
+	s.Delay("GET", time.Second)
+	c := scriptConn(s, &Config{ReadTimeout: 50 * time.Millisecond})
+	defer c.Close()
+
+	start := time.Now()
+	_, _, err := c.Get("/a", nil)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("Get from a hung server: got %v, want a timeout", err)
+	}
+	if d := time.Since(start); d >= time.Second {
+		t.Fatalf("Get took %v, not bounded by ReadTimeout", d)
+	}
+}
+
+func TestReadTimeoutIdleWait(t *testing.T) {
+	s := NewScript()
+	s.On("WAIT", "/a", Reply{Path: "/a", Body: []byte("x"), Rev: 2})
+	s.Delay("WAIT", 200*time.Millisecond)
+	c := scriptConn(s, &Config{ReadTimeout: 50 * time.Millisecond})
+	defer c.Close()
+
+	ev, err := c.Wait("/a", 1)
+	if err != nil {
+		t.Fatalf("Wait with no other request outstanding: %v", err)
+	}
+	if ev.Rev != 2 {
+		t.Fatalf("Wait returned rev %d, want 2", ev.Rev)
+	}
+}
+
+func TestResyncDrains(t *testing.T) {
+	s := NewScript()
+	s.On("WAIT", "/*", Reply{Err: ErrTooLate}, Reply{Path: "/d", Rev: 11})
+	s.On("REV", "", Reply{Rev: 10})
+	s.On("WALK", "/*",
+		Reply{Path: "/b", Rev: 7},
+		Reply{Path: "/a", Rev: 5},
+		Reply{Path: "/c", Rev: 2},
+		Reply{Err: ErrRange},
+	)
+	c := scriptConn(s, &Config{Resync: true})
+	defer c.Close()
+
+	// Every file the resync read that changed since rev comes back in
+	// order, from a single walk, before waits resume past it.
+	rev := int64(3)
+	for _, want := range []string{"/a", "/b", "/d"} {
+		ev, err := c.Wait("/*", rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Path != want {
+			t.Fatalf("Wait from rev %d returned %s, want %s", rev, ev.Path, want)
+		}
+		rev = ev.Rev + 1
+	}
+}
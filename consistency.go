@@ -0,0 +1,82 @@
+package doozer
+
+import (
+	"strings"
+)
+
+// A Consistency is the staleness a read of a path can tolerate.
+type Consistency int
+
+const (
+	// Cacheable reads may be answered from a cache, or share the
+	// response to an identical request already in flight.
+	Cacheable Consistency = iota
+
+	// Linearizable reads of the current revision must go to the
+	// server, and see every write that completed before they began.
+	Linearizable
+)
+
+// Contracts declare the consistency required of reads beneath
+// path prefixes, such as
+//
+//	doozer.Contracts{
+//		"/locks":  doozer.Linearizable,
+//		"/config": doozer.Cacheable,
+//	}
+//
+// Layers that could serve a stale read, such as request sharing,
+// View, and other caches, consult the contracts of their Conn first.
+// Reads of a pinned revision can never be stale, so contracts only
+// restrict reads of the current revision.
+type Contracts map[string]Consistency
+
+// Lookup returns the consistency required of reads of path: that of
+// the longest prefix of path in cs, or Cacheable if there is none.
+// A prefix matches whole path components only, so "/a" matches "/a"
+// and "/a/b" but not "/ab".
+func (cs Contracts) Lookup(path string) Consistency {
+	best, c := -1, Cacheable
+	for prefix, pc := range cs {
+		if len(prefix) > best && hasPathPrefix(path, prefix) {
+			best, c = len(prefix), pc
+		}
+	}
+	return c
+}
+
+// linearizable reports whether cs requires any path to be read
+// linearizably.
+func (cs Contracts) linearizable() bool {
+	for _, c := range cs {
+		if c == Linearizable {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" || prefix == path {
+		return true
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// Consistency returns the consistency c's contracts require of
+// reads of path.
+func (c *Conn) Consistency(path string) Consistency {
+	return c.cfg.Contracts.Lookup(path)
+}
+
+// consistencyOf returns the consistency required of reads of path
+// through c, for clients that declare contracts.
+func consistencyOf(c Client, path string) Consistency {
+	if cc, ok := c.(interface {
+		Consistency(path string) Consistency
+	}); ok {
+		return cc.Consistency(path)
+	}
+	return Cacheable
+}
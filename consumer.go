@@ -0,0 +1,124 @@
+package doozer
+
+import (
+	"context"
+	"strconv"
+)
+
+// A ConsumerFunc processes one change for a Consumer.
+type ConsumerFunc func(ev Event) error
+
+// A Consumer processes, in order, the changes to files matching a
+// glob, recording the revision of the last one processed in a file
+// of its own, its checkpoint. After a restart, it carries on from
+// the change after the checkpoint, so each change is processed once,
+// unless the process stops between processing a change and recording
+// it, in which case that change is processed again.
+//
+// The checkpoint is written only if it has not changed since the
+// Consumer last read or wrote it, so a second Consumer sharing the
+// checkpoint fails with ErrOldRev instead of repeating work.
+type Consumer struct {
+	// Start is the revision to start from when there is no
+	// checkpoint yet. Zero means the revision after the current one.
+	Start int64
+
+	c    *Conn
+	glob string
+	path string
+	frev int64 // revision of the checkpoint file
+}
+
+// NewConsumer returns a Consumer of changes to files matching glob
+// that keeps its checkpoint at path. Changes to path itself are
+// not processed.
+func NewConsumer(c *Conn, glob, path string) *Consumer {
+	return &Consumer{c: c, glob: glob, path: path}
+}
+
+// Run calls f with each change after the checkpoint, recording each
+// in turn as processed once f returns nil. It returns when f fails,
+// returning its error, or when ctx is done. If the server has
+// discarded the history Run needs, it returns a *GapError; see
+// ResumeAt.
+func (k *Consumer) Run(ctx context.Context, f ConsumerFunc) error {
+	rev, err := k.load()
+	if err != nil {
+		return err
+	}
+
+	evs, cancel, err := k.c.WatchGaps(k.glob, rev)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	for {
+		select {
+		case ev, ok := <-evs:
+			if !ok {
+				return cancel()
+			}
+			if ev.Path == k.path {
+				continue
+			}
+			err = f(ev)
+			if err != nil {
+				return err
+			}
+			err = k.save(ev.Rev)
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ResumeAt moves the checkpoint so that the next Run starts at rev.
+// After a *GapError, for example, read the files as of its To, then
+// resume at To+1.
+func (k *Consumer) ResumeAt(rev int64) error {
+	_, err := k.load()
+	if err != nil {
+		return err
+	}
+	return k.save(rev - 1)
+}
+
+// load reads the checkpoint and returns the revision to start from.
+func (k *Consumer) load() (int64, error) {
+	body, frev, err := k.c.Get(k.path, nil)
+	if err != nil {
+		return 0, err
+	}
+	k.frev = frev
+
+	if frev == missing {
+		if k.Start != 0 {
+			return k.Start, nil
+		}
+		rev, err := k.c.Rev()
+		if err != nil {
+			return 0, err
+		}
+		return rev + 1, nil
+	}
+
+	rev, err := strconv.ParseInt(string(body), 10, 64)
+	if err != nil {
+		return 0, &Error{ErrBadCheckpoint, k.path}
+	}
+	return rev + 1, nil
+}
+
+// save records rev as the last revision processed.
+func (k *Consumer) save(rev int64) error {
+	frev, err := k.c.Set(k.path, k.frev, []byte(strconv.FormatInt(rev, 10)))
+	if err != nil {
+		return err
+	}
+	k.frev = frev
+	return nil
+}
@@ -0,0 +1,115 @@
+package doozer
+
+import (
+	"context"
+)
+
+// A NameIter steps through the names in a directory, in
+// lexicographical order. Each name is requested only when Next is
+// called, so a caller can stop at any point without having asked
+// for more than it used.
+//
+// For example:
+//
+//	it := c.GetdirIter(ctx, "/svc", rev)
+//	for it.Next() {
+//		fmt.Println(it.Name())
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type NameIter struct {
+	c    *Conn
+	ctx  context.Context
+	dir  string
+	rev  int64
+	off  int
+	name string
+	err  error
+	done bool
+}
+
+// GetdirIter returns a NameIter over the names in dir, at revision
+// rev. It gives up when ctx is done.
+func (c *Conn) GetdirIter(ctx context.Context, dir string, rev int64) *NameIter {
+	return &NameIter{c: c, ctx: ctx, dir: dir, rev: rev}
+}
+
+// Next requests the next name. It returns false at the end of the
+// directory or on error.
+func (it *NameIter) Next() bool {
+	if it.done {
+		return false
+	}
+	name, err := it.c.getdirAt(it.ctx, it.dir, it.rev, it.off)
+	if err, ok := err.(*Error); ok && err.Err == ErrRange {
+		it.done = true
+		return false
+	}
+	if err != nil {
+		it.err, it.done = err, true
+		return false
+	}
+	it.name = name
+	it.off++
+	return true
+}
+
+// Name returns the name read by the last call to Next.
+func (it *NameIter) Name() string {
+	return it.name
+}
+
+// Err returns the error that ended the iteration, if any.
+func (it *NameIter) Err() error {
+	return it.err
+}
+
+// An EventIter steps through the files matching a glob, in
+// lexicographical order, requesting each only when Next is called.
+type EventIter struct {
+	c    *Conn
+	ctx  context.Context
+	glob string
+	rev  int64
+	off  int
+	ev   Event
+	err  error
+	done bool
+}
+
+// WalkIter returns an EventIter over the files matching glob, at
+// revision rev. It gives up when ctx is done.
+func (c *Conn) WalkIter(ctx context.Context, glob string, rev int64) *EventIter {
+	return &EventIter{c: c, ctx: ctx, glob: glob, rev: rev}
+}
+
+// Next requests the next file. It returns false after the last
+// matching file or on error.
+func (it *EventIter) Next() bool {
+	if it.done {
+		return false
+	}
+	ev, err := it.c.walkAt(it.ctx, it.glob, it.rev, it.off)
+	if err, ok := err.(*Error); ok && err.Err == ErrRange {
+		it.done = true
+		return false
+	}
+	if err != nil {
+		it.err, it.done = err, true
+		return false
+	}
+	it.ev = ev
+	it.off++
+	return true
+}
+
+// Event returns the file read by the last call to Next.
+func (it *EventIter) Event() Event {
+	return it.ev
+}
+
+// Err returns the error that ended the iteration, if any.
+func (it *EventIter) Err() error {
+	return it.err
+}
@@ -0,0 +1,64 @@
+package doozer
+
+import (
+	"bytes"
+	"sort"
+)
+
+// A Change describes a single file that differs between two
+// revisions of the store.
+type Change struct {
+	Path    string
+	OldRev  int64  // rev of the file before the change; 0 if it was missing
+	Del     bool   // whether the file was deleted
+	Body    []byte // body after the change; nil for a deletion
+	OldBody []byte // body before the change, as reported by Diff
+}
+
+// Added reports whether ch creates a file that was missing.
+func (ch Change) Added() bool {
+	return !ch.Del && ch.OldRev == missing
+}
+
+// Diff returns the changes, in lexicographical order by path, that
+// take the files matching glob in revision a to their state in
+// revision b. Each change holds the file's body in both revisions.
+func Diff(c Client, glob string, a, b int64) ([]Change, error) {
+	old, err := c.Walk(glob, a, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := c.Walk(glob, b, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	was := make(map[string]Event, len(old))
+	for _, ev := range old {
+		was[ev.Path] = ev
+	}
+
+	var changes []Change
+	for _, ev := range cur {
+		prev, ok := was[ev.Path]
+		delete(was, ev.Path)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: ev.Path, OldRev: missing, Body: ev.Body})
+		case prev.Rev != ev.Rev || !bytes.Equal(prev.Body, ev.Body):
+			changes = append(changes, Change{Path: ev.Path, OldRev: prev.Rev, Body: ev.Body, OldBody: prev.Body})
+		}
+	}
+	for _, ev := range was {
+		changes = append(changes, Change{Path: ev.Path, OldRev: ev.Rev, Del: true, OldBody: ev.Body})
+	}
+
+	sort.Sort(byPath(changes))
+	return changes, nil
+}
+
+type byPath []Change
+
+func (a byPath) Len() int           { return len(a) }
+func (a byPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
+func (a byPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -0,0 +1,406 @@
+// Package doozertest provides an in-memory doozer store for testing
+// code that uses a doozer.Client, without running doozerd.
+//
+// The store keeps revisions the way doozerd does: every change gets
+// the next revision, reads can be made at any retained revision, and
+// Wait reports the first change on or after a revision to a file
+// matching a glob.
+package doozertest
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	missing = 0
+	clobber = -1
+	dir     = -2
+)
+
+const (
+	set = 4
+	del = 8
+)
+
+// A version is the state of a file as of a revision.
+type version struct {
+	rev  int64
+	body []byte
+	del  bool
+}
+
+// A Server is an in-memory doozer store shared by its clients.
+type Server struct {
+	// Secret, if set, must be given to Access before
+	// a client may do anything else.
+	Secret string
+
+	// History is the number of past revisions retained.
+	// Reads and waits before them fail with ErrTooLate.
+	// Zero means every revision is retained.
+	History int64
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	rev   int64
+	files map[string][]version // path -> versions, oldest first
+	log   []doozer.Event
+}
+
+// NewServer returns an empty store at revision 0.
+func NewServer() *Server {
+	s := &Server{files: make(map[string][]version)}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Client returns a new client of s.
+func (s *Server) Client() *Client {
+	return &Client{s: s, authed: s.Secret == ""}
+}
+
+// Rev returns the current revision of s.
+func (s *Server) Rev() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rev
+}
+
+// A Client is a connection to a Server. It implements doozer.Client.
+type Client struct {
+	s      *Server
+	authed bool
+	closed bool
+}
+
+var _ doozer.Client = (*Client)(nil)
+
+func newError(err error) error {
+	return &doozer.Error{Err: err}
+}
+
+// check must be called with c.s.mu held.
+func (c *Client) check() error {
+	if c.closed {
+		return doozer.ErrClosed
+	}
+	if !c.authed {
+		return newError(doozer.ErrAccess)
+	}
+	return nil
+}
+
+// at returns the state of path at rev, and whether it existed.
+// It must be called with s.mu held.
+func (s *Server) at(path string, rev int64) (version, bool) {
+	vs := s.files[path]
+	i := sort.Search(len(vs), func(i int) bool { return vs[i].rev > rev })
+	if i == 0 || vs[i-1].del {
+		return version{}, false
+	}
+	return vs[i-1], true
+}
+
+// children returns the sorted names of the entries in d at rev,
+// and whether d is a directory. It must be called with s.mu held.
+func (s *Server) children(d string, rev int64) ([]string, bool) {
+	prefix := d + "/"
+	if d == "/" {
+		prefix = "/"
+	}
+	seen := make(map[string]bool)
+	for path := range s.files {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if _, ok := s.at(path, rev); !ok {
+			continue
+		}
+		name := path[len(prefix):]
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+		}
+		seen[name] = true
+	}
+	if len(seen) == 0 && d != "/" {
+		return nil, false
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// readRev resolves rev for a read. It must be called with s.mu held.
+func (s *Server) readRev(rev *int64) (int64, error) {
+	if rev == nil {
+		return s.rev, nil
+	}
+	if *rev > s.rev {
+		// doozerd waits for the revision; reads here never block.
+		return 0, &doozer.Error{Err: doozer.ErrRange, Detail: "future revision"}
+	}
+	if s.History > 0 && *rev < s.rev-s.History {
+		return 0, newError(doozer.ErrTooLate)
+	}
+	return *rev, nil
+}
+
+func validPath(path string) bool {
+	return strings.HasPrefix(path, "/") && (path == "/" || !strings.HasSuffix(path, "/")) &&
+		!strings.Contains(path, "//")
+}
+
+func (c *Client) Access(token string) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	if c.closed {
+		return doozer.ErrClosed
+	}
+	if token != c.s.Secret {
+		return &doozer.Error{Err: doozer.ErrOther, Detail: "invalid"}
+	}
+	c.authed = true
+	return nil
+}
+
+func (c *Client) Get(file string, rev *int64) ([]byte, int64, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := c.check(); err != nil {
+		return nil, 0, err
+	}
+	r, err := s.readRev(rev)
+	if err != nil {
+		return nil, 0, err
+	}
+	if v, ok := s.at(file, r); ok {
+		return clone(v.body), v.rev, nil
+	}
+	if _, ok := s.children(file, r); ok {
+		return nil, 0, newError(doozer.ErrIsDir)
+	}
+	return nil, missing, nil
+}
+
+func (c *Client) Set(file string, oldRev int64, body []byte) (int64, error) {
+	return c.write(file, oldRev, body, false)
+}
+
+func (c *Client) Del(file string, rev int64) error {
+	_, err := c.write(file, rev, nil, true)
+	return err
+}
+
+func (c *Client) write(file string, oldRev int64, body []byte, isDel bool) (int64, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+	if !validPath(file) || file == "/" {
+		return 0, &doozer.Error{Err: doozer.ErrOther, Detail: "bad path"}
+	}
+	if _, ok := s.children(file, s.rev); ok {
+		return 0, newError(doozer.ErrIsDir)
+	}
+	for p := file; ; {
+		p = p[:strings.LastIndex(p, "/")]
+		if p == "" {
+			break
+		}
+		if _, ok := s.at(p, s.rev); ok {
+			return 0, newError(doozer.ErrNotDir)
+		}
+	}
+
+	cur := int64(missing)
+	if v, ok := s.at(file, s.rev); ok {
+		cur = v.rev
+	}
+	if oldRev != clobber && cur > oldRev {
+		return 0, newError(doozer.ErrOldRev)
+	}
+
+	s.rev++
+	body = append([]byte(nil), body...)
+	s.files[file] = append(s.files[file], version{s.rev, body, isDel})
+	ev := doozer.Event{Rev: s.rev, Path: file, Body: body, Flag: set}
+	if isDel {
+		ev.Body = nil
+		ev.Flag = del
+	}
+	s.log = append(s.log, ev)
+	s.cond.Broadcast()
+	return s.rev, nil
+}
+
+func (c *Client) Getdir(d string, rev int64, off, lim int) ([]string, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	r, err := s.readRev(&rev)
+	if err != nil {
+		return nil, err
+	}
+	names, ok := s.children(d, r)
+	if !ok {
+		if _, ok := s.at(d, r); ok {
+			return nil, newError(doozer.ErrNotDir)
+		}
+		return nil, newError(doozer.ErrNoEnt)
+	}
+	return window(names, off, lim), nil
+}
+
+// clone returns a copy of b, so callers cannot modify the store.
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func window(names []string, off, lim int) []string {
+	if off >= len(names) {
+		return nil
+	}
+	names = names[off:]
+	if lim >= 0 && lim < len(names) {
+		names = names[:lim]
+	}
+	return names
+}
+
+func (c *Client) Stat(path string, storeRev *int64) (int, int64, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := c.check(); err != nil {
+		return 0, 0, err
+	}
+	r, err := s.readRev(storeRev)
+	if err != nil {
+		return 0, 0, err
+	}
+	if v, ok := s.at(path, r); ok {
+		return len(v.body), v.rev, nil
+	}
+	if names, ok := s.children(path, r); ok {
+		return len(names), dir, nil
+	}
+	return 0, missing, nil
+}
+
+func (c *Client) Walk(glob string, rev int64, off, lim int) ([]doozer.Event, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	g, err := doozer.CompileGlob(glob)
+	if err != nil {
+		return nil, &doozer.Error{Err: doozer.ErrOther, Detail: err.Error()}
+	}
+	r, err := s.readRev(&rev)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path := range s.files {
+		if _, ok := s.at(path, r); ok && g.Match(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var evs []doozer.Event
+	for _, path := range window(paths, off, lim) {
+		v, _ := s.at(path, r)
+		evs = append(evs, doozer.Event{Rev: v.rev, Path: path, Body: clone(v.body), Flag: set})
+	}
+	return evs, nil
+}
+
+func (c *Client) Wait(glob string, rev int64) (doozer.Event, error) {
+	return c.WaitContext(context.Background(), glob, rev)
+}
+
+// WaitContext is like Wait, but gives up when ctx is done, returning
+// ctx's error, as doozer.Conn's WaitContext does.
+func (c *Client) WaitContext(ctx context.Context, glob string, rev int64) (doozer.Event, error) {
+	s := c.s
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, err := doozer.CompileGlob(glob)
+	if err != nil {
+		return doozer.Event{}, &doozer.Error{Err: doozer.ErrOther, Detail: err.Error()}
+	}
+
+	for {
+		if err := c.check(); err != nil {
+			return doozer.Event{}, err
+		}
+		if err := ctx.Err(); err != nil {
+			return doozer.Event{}, err
+		}
+		if s.History > 0 && rev < s.rev-s.History {
+			return doozer.Event{}, newError(doozer.ErrTooLate)
+		}
+
+		// The log holds one event per revision, starting at 1.
+		i := rev - 1
+		if i < 0 {
+			i = 0
+		}
+		for ; i < int64(len(s.log)); i++ {
+			if ev := s.log[i]; g.Match(ev.Path) {
+				ev.Body = clone(ev.Body)
+				return ev, nil
+			}
+		}
+		if rev <= s.rev {
+			rev = s.rev + 1
+		}
+		s.cond.Wait()
+	}
+}
+
+func (c *Client) Rev() (int64, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+	return s.rev, nil
+}
+
+// Close makes further operations on c fail with doozer.ErrClosed,
+// including any Wait in progress.
+func (c *Client) Close() {
+	c.s.mu.Lock()
+	c.closed = true
+	c.s.cond.Broadcast()
+	c.s.mu.Unlock()
+}
@@ -0,0 +1,47 @@
+package doozertest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitFutureRev(t *testing.T) {
+	s := NewServer()
+	c := s.Client()
+	defer c.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.Set("/a", -1, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const want = 10
+	got := make(chan int64, 1)
+	go func() {
+		ev, err := c.Wait("/a", want)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- ev.Rev
+	}()
+
+	// Revisions 6 through 9 match, but come before the one asked for.
+	for i := 0; i < want-1-5; i++ {
+		if _, err := c.Set("/a", -1, []byte("y")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case rev := <-got:
+		t.Fatalf("Wait returned rev %d, before %d", rev, want)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, err := c.Set("/a", -1, []byte("z")); err != nil {
+		t.Fatal(err)
+	}
+	if rev := <-got; rev != want {
+		t.Fatalf("Wait returned rev %d, want %d", rev, want)
+	}
+}
@@ -0,0 +1,96 @@
+package doozer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var (
+	ErrBadDump = errors.New("malformed dump")
+)
+
+// A DumpRecord is one line of a dump written by Dump and read by
+// Restore.
+type DumpRecord struct {
+	Path string `json:"path"`
+	Rev  int64  `json:"rev"`  // revision at which the file was last set
+	Body []byte `json:"body"` // base64-encoded in the dump
+}
+
+// Dump writes the files matching glob, as of revision rev, to w, in
+// lexicographical order by path. The dump is in JSON Lines format:
+// each line is a DumpRecord, encoded as a JSON object such as
+//
+//	{"path":"/a/b","rev":12,"body":"aGVsbG8="}
+//
+// It returns the number of files written.
+func Dump(c Client, glob string, rev int64, w io.Writer) (n int, err error) {
+	evs, err := walkAll(c, glob, rev)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, ev := range evs {
+		err = enc.Encode(DumpRecord{ev.Path, ev.Rev, ev.Body})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, bw.Flush()
+}
+
+// A RestoreMode says what Restore does with a file in the dump.
+type RestoreMode int
+
+const (
+	// RestoreOverwrite writes each file, whatever is there now.
+	RestoreOverwrite RestoreMode = iota
+
+	// RestoreSkipExisting writes each file that does not exist,
+	// leaving those that do as they are.
+	RestoreSkipExisting
+
+	// RestoreStrict writes each file on the condition that its
+	// revision is still the one recorded in the dump, failing
+	// otherwise.
+	RestoreStrict
+)
+
+// Restore writes the files in a dump read from r, as written by Dump,
+// in order, according to mode. It stops at the first file that cannot
+// be written, returning the number of files written so far and a
+// *PatchError. A malformed dump fails with ErrBadDump.
+func Restore(c Client, r io.Reader, mode RestoreMode) (n int, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		var rec DumpRecord
+		err = dec.Decode(&rec)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil || rec.Path == "" {
+			return n, ErrBadDump
+		}
+
+		oldRev := clobber
+		switch mode {
+		case RestoreSkipExisting:
+			oldRev = missing
+		case RestoreStrict:
+			oldRev = rec.Rev
+		}
+		_, err = c.Set(rec.Path, oldRev, rec.Body)
+		if e, ok := err.(*Error); ok && e.Err == ErrOldRev && mode == RestoreSkipExisting {
+			continue
+		}
+		if err != nil {
+			return n, &PatchError{rec.Path, err}
+		}
+		n++
+	}
+}
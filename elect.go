@@ -0,0 +1,126 @@
+package doozer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// An Elector campaigns to be the leader among the processes electing
+// one under a path. Leadership is held with a Lock at that path, so
+// a leader that loses touch with the cluster learns it is no longer
+// leader once its Session lapses, and is replaced once the Session
+// is reaped.
+type Elector struct {
+	// C receives true when the Elector becomes leader and false when
+	// it stops being leader. It must be received from promptly. It
+	// is closed by Close, or once the Session lapses.
+	C <-chan bool
+
+	lock   *Lock
+	cancel context.CancelFunc
+	done   chan bool
+
+	mu     sync.Mutex
+	leader bool
+	err    error
+}
+
+// Campaign starts an Elector campaigning under path, as part of s.
+func Campaign(s *Session, path string) *Elector {
+	lock := NewLock(s, path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan bool)
+	e := &Elector{
+		C:      ch,
+		lock:   lock,
+		cancel: cancel,
+		done:   make(chan bool),
+	}
+	go e.run(ctx, ch)
+	return e
+}
+
+// Leader reports whether the Elector is leader, and if so, its
+// fencing token, as given by Lock.
+func (e *Elector) Leader() (leader bool, token int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.leader {
+		return false, 0
+	}
+	return true, e.lock.Token()
+}
+
+// Close stops campaigning and, if the Elector is leader, resigns,
+// so another may be elected at once.
+func (e *Elector) Close() error {
+	e.cancel()
+	<-e.done
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+func (e *Elector) run(ctx context.Context, ch chan bool) {
+	defer close(e.done)
+	defer close(ch)
+
+	backoff := ExponentialBackoff(10*time.Millisecond, time.Second)
+	failures := 0
+	for {
+		_, err := e.lock.Acquire(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == ErrSessionLost {
+			e.mu.Lock()
+			e.err = err
+			e.mu.Unlock()
+			return
+		}
+		if err != nil {
+			failures++
+			timer := time.NewTimer(backoff(failures))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		e.setLeader(true)
+		select {
+		case ch <- true:
+		case <-ctx.Done():
+		}
+
+		select {
+		case <-e.lock.Done():
+			e.lock.Release()
+			e.setLeader(false)
+			select {
+			case ch <- false:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			err := e.lock.Release()
+			e.mu.Lock()
+			e.leader = false
+			e.err = err
+			e.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	e.leader = leader
+	e.mu.Unlock()
+}
@@ -2,12 +2,36 @@ package doozer
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
-	ErrNoAddrs = errors.New("no known address")
-	ErrBadTag  = errors.New("bad tag")
-	ErrClosed  = errors.New("closed")
+	ErrNoAddrs     = errors.New("no known address")
+	ErrBadTag      = errors.New("bad tag")
+	ErrClosed      = errors.New("closed")
+	ErrAccess      = errors.New("permission denied")
+	ErrUnavailable = errors.New("cluster unavailable")
+	ErrDiverged    = errors.New("members disagree")
+	ErrBusy        = errors.New("too many requests in flight")
+	ErrFrameSize   = errors.New("frame exceeds maximum message size")
+	ErrNoTags      = errors.New("every tag is in use")
+	ErrWaitTimeout = errors.New("wait timed out")
+	ErrUnsupported = errors.New("not supported by the server")
+
+	ErrBadCheckpoint = errors.New("checkpoint is not a revision")
+	ErrSkipped       = errors.New("skipped after an earlier failure")
+	ErrExists        = errors.New("file exists")
+	ErrLocked        = errors.New("lock is held by another")
+	ErrLockLost      = errors.New("lock is not held")
+	ErrSessionLost   = errors.New("session has lapsed")
+	ErrLeaseHeld     = errors.New("lease is held by another")
+	ErrLeaseLost     = errors.New("lease is not held")
+	ErrNotMessage    = errors.New("value is not a protocol buffer message")
+	ErrNotPointer    = errors.New("value is not a non-nil pointer")
+	ErrCorrupt       = errors.New("body does not match its checksum")
+	ErrNotModified   = errors.New("file not modified")
+	ErrBadPath       = errors.New("bad path")
+	ErrBadManifest   = errors.New("malformed manifest")
 )
 
 var (
@@ -21,13 +45,23 @@ var (
 	ErrReadonly response_Err = response_READONLY
 )
 
+// isTooLate reports whether err is the server's ErrTooLate.
+func isTooLate(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Err == ErrTooLate
+}
+
 type Error struct {
 	Err    error
 	Detail string
 }
 
 func newError(t *txn) (err *Error) {
-	if t.resp.ErrDetail != nil {
+	if *t.resp.ErrCode == ErrOther && t.resp.ErrDetail != nil && *t.resp.ErrDetail == ErrAccess.Error() {
+		err = &Error{
+			Err: ErrAccess,
+		}
+	} else if t.resp.ErrDetail != nil {
 		err = &Error{
 			Err:    *t.resp.ErrCode,
 			Detail: *t.resp.ErrDetail,
@@ -48,3 +82,16 @@ func (e *Error) Error() (s string) {
 	}
 	return s
 }
+
+// A GapError reports that the server discarded the history of changes
+// to files matching Glob, from revision From through To, before they
+// could be delivered. To catch up, read the files as of To and carry
+// on from To+1.
+type GapError struct {
+	Glob     string
+	From, To int64
+}
+
+func (e *GapError) Error() string {
+	return fmt.Sprintf("changes to %s lost from rev %d through %d", e.Glob, e.From, e.To)
+}
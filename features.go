@@ -0,0 +1,134 @@
+package doozer
+
+import (
+	"context"
+	"google.golang.org/protobuf/proto"
+	"sort"
+)
+
+// Features describes what the server a Conn is connected to is known
+// to support, as learned by probing it when Config.Probe is set, and
+// from the requests it has refused since.
+type Features struct {
+	Version     string   // the server's version, if it publishes one
+	Verbs       []string // requests the server answered when probed
+	Unsupported []string // requests the server does not know
+
+	// MaxMessageSize is the largest frame exchanged with the server.
+	MaxMessageSize int
+}
+
+// features is what a Conn has learned about its server.
+type features struct {
+	version string
+	verbs   map[request_Verb]bool // true if answered, false if refused
+}
+
+// probeVerbs are the requests a probe sends, after REV, to see which
+// the server knows. Each is harmless, reading at a fixed revision.
+var probeVerbs = []request_Verb{
+	request_NOP,
+	request_GET,
+	request_GETDIR,
+	request_STAT,
+	request_WALK,
+}
+
+// Features returns what is known of the server c is connected to.
+func (c *Conn) Features() Features {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := Features{
+		Version:        c.feat.version,
+		MaxMessageSize: c.cfg.maxMessageSize(),
+	}
+	for v, ok := range c.feat.verbs {
+		if ok {
+			f.Verbs = append(f.Verbs, request_Verb_name[int32(v)])
+		} else {
+			f.Unsupported = append(f.Unsupported, request_Verb_name[int32(v)])
+		}
+	}
+	sort.Strings(f.Verbs)
+	sort.Strings(f.Unsupported)
+	return f
+}
+
+// Probe asks the server which requests it supports, and reads its
+// version from /ctl/node, replacing what c knew of its features.
+// A request the server refuses, now or later, fails at once with
+// ErrUnsupported from then on.
+func (c *Conn) Probe(ctx context.Context) error {
+	c.mu.Lock()
+	c.feat = features{}
+	c.mu.Unlock()
+
+	rev, err := c.rev(ctx)
+	if err != nil {
+		return err
+	}
+	c.noteVerb(request_REV, true)
+
+	for _, v := range probeVerbs {
+		var t txn
+		t.req.Verb = v.Enum()
+		t.req.Rev = &rev
+		if v != request_NOP {
+			t.req.Path = proto.String("/")
+			if v == request_WALK {
+				t.req.Path = proto.String("/**")
+			}
+		}
+
+		err = c.callContext(ctx, &t)
+		if isUnsupported(err) {
+			continue
+		}
+		if _, ok := err.(*Error); err != nil && !ok {
+			return err
+		}
+		c.noteVerb(v, true)
+	}
+
+	// Not every server publishes its version.
+	nodes, err := Nodes(c, rev)
+	if err == nil {
+		if n, err := c.self(nodes); err == nil {
+			c.mu.Lock()
+			c.feat.version = n.Version
+			c.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// noteVerb records whether c's server supports v.
+func (c *Conn) noteVerb(v request_Verb, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.feat.verbs == nil {
+		c.feat.verbs = make(map[request_Verb]bool)
+	}
+	c.feat.verbs[v] = ok
+}
+
+// refused reports whether c's server is known not to support v.
+func (c *Conn) refused(v request_Verb) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ok, known := c.feat.verbs[v]
+	return known && !ok
+}
+
+func isUnknownVerb(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Err == response_UNKNOWN_VERB
+}
+
+func isUnsupported(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Err == ErrUnsupported
+}
@@ -0,0 +1,104 @@
+package doozer
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Flags is a store of feature flags, the files under a prefix,
+// kept current by a Mirror. Each getter reads the flag's file,
+// prefix/<key>, parsing its body as the type asked for, and returns
+// the default it is given if the file is missing or cannot be
+// parsed. An override, given to With, takes precedence over the
+// file.
+type Flags struct {
+	m         *Mirror
+	prefix    string
+	overrides map[string]string // never modified once set
+	derived   bool              // whether made by With, sharing m
+}
+
+// NewFlags returns Flags for the flags under prefix.
+func NewFlags(c *Conn, prefix string) (*Flags, error) {
+	m, err := NewMirror(c, prefix+"/*")
+	if err != nil {
+		return nil, err
+	}
+	return &Flags{m: m, prefix: prefix}, nil
+}
+
+// With returns a view of f in which the flag key reads as value,
+// whatever its file holds, such as for a single request or test.
+// f itself, and any other view of it, is unaffected.
+func (f *Flags) With(key, value string) *Flags {
+	overrides := make(map[string]string, len(f.overrides)+1)
+	for k, v := range f.overrides {
+		overrides[k] = v
+	}
+	overrides[key] = value
+	return &Flags{m: f.m, prefix: f.prefix, overrides: overrides, derived: true}
+}
+
+func (f *Flags) get(key string) (string, bool) {
+	if v, ok := f.overrides[key]; ok {
+		return v, true
+	}
+	body, _, ok := f.m.Get(f.prefix + "/" + key)
+	return strings.TrimSpace(string(body)), ok
+}
+
+// String returns the flag key as a string.
+func (f *Flags) String(key, def string) string {
+	if v, ok := f.get(key); ok {
+		return v
+	}
+	return def
+}
+
+// Bool returns the flag key as a bool, as parsed by strconv.ParseBool.
+func (f *Flags) Bool(key string, def bool) bool {
+	if v, ok := f.get(key); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// Int returns the flag key as an int.
+func (f *Flags) Int(key string, def int) int {
+	if v, ok := f.get(key); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Percent reports whether the flag key, a percentage from 0 to 100,
+// is on for unit, such as a user ID. Each unit is placed by a hash
+// of key and unit, so a given unit stays on as the percentage grows.
+// The result for a missing or unparsable flag is def.
+func (f *Flags) Percent(key, unit string, def bool) bool {
+	v, ok := f.get(key)
+	if !ok {
+		return def
+	}
+	p, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil {
+		return def
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key + "\x00" + unit))
+	return float64(h.Sum32()%10000) < p*100
+}
+
+// Close stops keeping the flags current, for f and every view of it
+// made by With. Closing a view made by With does nothing.
+func (f *Flags) Close() error {
+	if f.derived {
+		return nil
+	}
+	return f.m.Close()
+}
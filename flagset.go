@@ -0,0 +1,120 @@
+package doozer
+
+import (
+	"flag"
+	"sync"
+)
+
+// LoadFlags sets each flag in fs that was not set on the command line
+// from the file prefix/<name>, if there is one, as if the file's body
+// had been given as the flag's value. Call it after fs.Parse.
+// Files that name no flag in fs are ignored.
+func LoadFlags(c *Conn, fs *flag.FlagSet, prefix string) error {
+	rev, err := c.Rev()
+	if err != nil {
+		return err
+	}
+	evs, err := c.Walk(prefix+"/*", rev, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, ev := range evs {
+		name := basename(ev.Path)
+		if given[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := setFlag(fs, name, ev.Path, ev.Body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A FlagWatcher keeps dynamic flags set from their files.
+type FlagWatcher struct {
+	m *Mirror
+
+	mu  sync.Mutex
+	err error
+}
+
+// WatchFlags sets the named flags in fs from their files under prefix,
+// as LoadFlags does, first from the files as they are, and then each
+// time they change, until Close is called. It fails with the first
+// error setting a flag from its file as it is. Flags set on the
+// command line are left alone. The flags' Values are set from
+// another goroutine, so they must be safe for concurrent use, or read
+// only under the caller's own locking.
+func WatchFlags(c *Conn, fs *flag.FlagSet, prefix string, names ...string) (*FlagWatcher, error) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	dynamic := make(map[string]bool)
+	for _, name := range names {
+		if !given[name] && fs.Lookup(name) != nil {
+			dynamic[name] = true
+		}
+	}
+
+	m, err := NewMirror(c, prefix+"/*")
+	if err != nil {
+		return nil, err
+	}
+	w := &FlagWatcher{m: m}
+	m.OnChange(func(ev Event) {
+		name := basename(ev.Path)
+		if !dynamic[name] || !ev.IsSet() {
+			return
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.err = setFlag(fs, name, ev.Path, ev.Body)
+	})
+
+	// Set the flags from the copy once changes to it are being
+	// applied, so that none is missed; w.mu orders the two.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, name := range names {
+		if !dynamic[name] {
+			continue
+		}
+		path := prefix + "/" + name
+		body, _, ok := m.Get(path)
+		if !ok {
+			continue
+		}
+		if err := setFlag(fs, name, path, body); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func setFlag(fs *flag.FlagSet, name, path string, body []byte) error {
+	if err := fs.Set(name, string(body)); err != nil {
+		return &Error{err, path}
+	}
+	return nil
+}
+
+// Err returns the error from the last change that could not be
+// applied to its flag, if the flags have not been set successfully
+// since.
+func (w *FlagWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close stops setting the flags.
+func (w *FlagWatcher) Close() error {
+	return w.m.Close()
+}
@@ -0,0 +1,64 @@
+package doozer
+
+import (
+	"strconv"
+	"sync"
+)
+
+// A flight is a call in progress or completed.
+type flight struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+	dup bool
+}
+
+// A group deduplicates concurrent calls with the same key,
+// so that only one of them is sent to the server.
+// The zero value is ready to use.
+type group struct {
+	mu sync.Mutex
+	m  map[string]*flight
+}
+
+// do calls f and returns its results. If a call with the same key
+// is already in progress, do waits for it and returns its results
+// instead. shared reports whether the results were given to more
+// than one caller.
+func (g *group) do(key string, f func() (interface{}, error)) (v interface{}, shared bool, err error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*flight)
+	}
+	if fl, ok := g.m[key]; ok {
+		fl.dup = true
+		g.mu.Unlock()
+		fl.wg.Wait()
+		return fl.val, true, fl.err
+	}
+	fl := new(flight)
+	fl.wg.Add(1)
+	g.m[key] = fl
+	g.mu.Unlock()
+
+	fl.val, fl.err = f()
+	fl.wg.Done()
+
+	g.mu.Lock()
+	delete(g.m, key)
+	shared = fl.dup
+	g.mu.Unlock()
+
+	return fl.val, shared, fl.err
+}
+
+func flightKey(verb, path string, rev *int64, args ...int) string {
+	key := verb + "\x00" + path + "\x00"
+	if rev != nil {
+		key += strconv.FormatInt(*rev, 10)
+	}
+	for _, n := range args {
+		key += "\x00" + strconv.Itoa(n)
+	}
+	return key
+}
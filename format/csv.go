@@ -0,0 +1,32 @@
+package format
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvFormatter struct {
+	w *csv.Writer
+}
+
+// CSV writes each record as a row of comma-separated values:
+// type, path, rev, len, and body.
+func CSV(w io.Writer) Formatter {
+	return &csvFormatter{csv.NewWriter(w)}
+}
+
+func (f *csvFormatter) Write(r Record) error {
+	return f.w.Write([]string{
+		r.Type,
+		r.Path,
+		strconv.FormatInt(r.Rev, 10),
+		strconv.Itoa(r.Len),
+		string(r.Body),
+	})
+}
+
+func (f *csvFormatter) Flush() error {
+	f.w.Flush()
+	return f.w.Error()
+}
@@ -0,0 +1,99 @@
+// Package format writes doozer events and file metadata in a choice
+// of output formats. The doozer command and the HTTP gateway both use
+// it, so a format registered here is available to each of them.
+package format
+
+import (
+	"errors"
+	"github.com/dcjones/doozer"
+	"io"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+)
+
+// A Record is one unit of output.
+type Record struct {
+	// Type is "set" or "del" for a change, "file" or "dir" for an
+	// entry in a listing, or a marker such as "sync".
+	Type string
+	Path string
+	Rev  int64
+	Len  int
+	Body []byte
+}
+
+// FromEvent returns the Record for ev.
+func FromEvent(ev doozer.Event) Record {
+	r := Record{Type: "set", Path: ev.Path, Rev: ev.Rev, Len: len(ev.Body), Body: ev.Body}
+	if ev.IsDel() {
+		r.Type = "del"
+	}
+	return r
+}
+
+// FromFileInfo returns the Record for the file or directory at path.
+func FromFileInfo(path string, fi *doozer.FileInfo) Record {
+	r := Record{Type: "file", Path: path, Rev: fi.Rev, Len: fi.Len}
+	if fi.IsDir {
+		r.Type = "dir"
+	}
+	return r
+}
+
+// A Formatter writes Records to an underlying writer.
+type Formatter interface {
+	Write(r Record) error
+
+	// Flush writes any buffered output.
+	Flush() error
+}
+
+// A Format returns a Formatter that writes to w.
+type Format func(w io.Writer) Formatter
+
+var (
+	mu      sync.Mutex
+	formats = make(map[string]Format)
+)
+
+// Register makes a format available by name.
+// It replaces any format already registered with the same name.
+func Register(name string, f Format) {
+	mu.Lock()
+	formats[name] = f
+	mu.Unlock()
+}
+
+// New returns a Formatter for the named format, writing to w.
+func New(name string, w io.Writer) (Formatter, error) {
+	mu.Lock()
+	f, ok := formats[name]
+	mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	return f(w), nil
+}
+
+// Names returns the names of the registered formats, in order.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("text", Text)
+	Register("json", JSON)
+	Register("csv", CSV)
+	Register("protobuf", Protobuf)
+}
@@ -0,0 +1,35 @@
+package format
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type jsonRecord struct {
+	Type string `json:"type"`
+	Rev  int64  `json:"rev"`
+	Path string `json:"path,omitempty"`
+	Len  int    `json:"len,omitempty"`
+	Body []byte `json:"body,omitempty"`
+}
+
+type jsonFormatter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// JSON writes each record as a JSON object on a line of its own,
+// with the body encoded in base64.
+func JSON(w io.Writer) Formatter {
+	bw := bufio.NewWriter(w)
+	return &jsonFormatter{bw, json.NewEncoder(bw)}
+}
+
+func (f *jsonFormatter) Write(r Record) error {
+	return f.enc.Encode(jsonRecord{r.Type, r.Rev, r.Path, r.Len, r.Body})
+}
+
+func (f *jsonFormatter) Flush() error {
+	return f.w.Flush()
+}
@@ -0,0 +1,62 @@
+package format
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+type protobufFormatter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// Protobuf writes each record as a protocol buffer message,
+//
+//	message Record {
+//	  optional string type = 1;
+//	  optional string path = 2;
+//	  optional int64 rev = 3;
+//	  optional int64 len = 4;
+//	  optional bytes body = 5;
+//	}
+//
+// preceded by its length as a 4-byte big-endian integer, the same
+// framing the doozer protocol uses.
+func Protobuf(w io.Writer) Formatter {
+	return &protobufFormatter{w: bufio.NewWriter(w)}
+}
+
+func (f *protobufFormatter) Write(r Record) error {
+	b := f.buf[:0]
+	b = appendBytes(b, 1, []byte(r.Type))
+	b = appendBytes(b, 2, []byte(r.Path))
+	b = appendVarint(b, 3, uint64(r.Rev))
+	b = appendVarint(b, 4, uint64(r.Len))
+	if r.Body != nil {
+		b = appendBytes(b, 5, r.Body)
+	}
+	f.buf = b
+
+	err := binary.Write(f.w, binary.BigEndian, int32(len(b)))
+	if err != nil {
+		return err
+	}
+	_, err = f.w.Write(b)
+	return err
+}
+
+func (f *protobufFormatter) Flush() error {
+	return f.w.Flush()
+}
+
+func appendVarint(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|0))
+	return binary.AppendUvarint(b, v)
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|2))
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,28 @@
+package format
+
+import (
+	"bufio"
+	"io"
+	"text/template"
+)
+
+type templateFormatter struct {
+	w *bufio.Writer
+	t *template.Template
+}
+
+// Template returns a Format that executes t for each record,
+// with the Record as its data.
+func Template(t *template.Template) Format {
+	return func(w io.Writer) Formatter {
+		return &templateFormatter{bufio.NewWriter(w), t}
+	}
+}
+
+func (f *templateFormatter) Write(r Record) error {
+	return f.t.Execute(f.w, r)
+}
+
+func (f *templateFormatter) Flush() error {
+	return f.w.Flush()
+}
@@ -0,0 +1,32 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+type textFormatter struct {
+	w *bufio.Writer
+}
+
+// Text writes each record as
+//
+//	<path> <rev> <type> <len> LF <body> LF
+//
+// where LF is an ASCII line-feed char. This is the format printed
+// by the doozer command's watch and wait.
+func Text(w io.Writer) Formatter {
+	return &textFormatter{bufio.NewWriter(w)}
+}
+
+func (f *textFormatter) Write(r Record) error {
+	fmt.Fprintln(f.w, r.Path, r.Rev, r.Type, r.Len)
+	f.w.Write(r.Body)
+	_, err := fmt.Fprintln(f.w)
+	return err
+}
+
+func (f *textFormatter) Flush() error {
+	return f.w.Flush()
+}
@@ -0,0 +1,181 @@
+//go:build go1.16
+
+package doozer
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// FS returns a read-only file system of the store as of s's
+// revision, for use with packages such as html/template, net/http,
+// and io/fs itself. File names are store paths without the leading
+// slash; "." names the root. The file system implements fs.StatFS,
+// fs.ReadDirFS, and fs.ReadFileFS.
+func (s *Snapshot) FS() fs.FS {
+	return &storeFS{s.c, s.rev}
+}
+
+type storeFS struct {
+	c   Client
+	rev int64
+}
+
+func (sfs *storeFS) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return "/", nil
+	}
+	return "/" + name, nil
+}
+
+func (sfs *storeFS) Stat(name string) (fs.FileInfo, error) {
+	path, err := sfs.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := Statinfo(sfs.c, sfs.rev, path)
+	if err != nil {
+		return nil, fsError("stat", name, err)
+	}
+	return fsInfo{*fi}, nil
+}
+
+func (sfs *storeFS) Open(name string) (fs.File, error) {
+	fi, err := sfs.Stat(name)
+	if err != nil {
+		err.(*fs.PathError).Op = "open"
+		return nil, err
+	}
+	if fi.IsDir() {
+		return &fsDir{sfs: sfs, name: name, info: fi}, nil
+	}
+	path, _ := sfs.path("open", name)
+	body, _, err := sfs.c.Get(path, &sfs.rev)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+	return &fsFile{fi, bytes.NewReader(body)}, nil
+}
+
+func (sfs *storeFS) ReadFile(name string) ([]byte, error) {
+	f, err := sfs.Open(name)
+	if err != nil {
+		err.(*fs.PathError).Op = "read"
+		return nil, err
+	}
+	defer f.Close()
+	if f, ok := f.(*fsFile); ok {
+		return io.ReadAll(f)
+	}
+	return nil, &fs.PathError{Op: "read", Path: name, Err: ErrIsDir}
+}
+
+func (sfs *storeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	path, err := sfs.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := Getdirinfo(sfs.c, path, sfs.rev, 0, -1)
+	if err != nil {
+		return nil, fsError("readdir", name, err)
+	}
+	ents := make([]fs.DirEntry, len(fis))
+	for i, fi := range fis {
+		ents[i] = fs.FileInfoToDirEntry(fsInfo{fi})
+	}
+	sort.Slice(ents, func(i, j int) bool { return ents[i].Name() < ents[j].Name() })
+	return ents, nil
+}
+
+// fsError converts err from the store to the form io/fs expects.
+func fsError(op, name string, err error) error {
+	if err == ErrNoEnt {
+		err = fs.ErrNotExist
+	}
+	if e, ok := err.(*Error); ok && e.Err == ErrNoEnt {
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// fsInfo is a FileInfo as an fs.FileInfo.
+type fsInfo struct {
+	fi FileInfo
+}
+
+func (i fsInfo) Name() string {
+	if i.fi.Name == "" {
+		return "."
+	}
+	return i.fi.Name
+}
+
+func (i fsInfo) Size() int64 {
+	if i.fi.IsDir {
+		return 0
+	}
+	return int64(i.fi.Len)
+}
+
+func (i fsInfo) Mode() fs.FileMode {
+	if i.fi.IsDir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (i fsInfo) ModTime() time.Time { return time.Time{} }
+func (i fsInfo) IsDir() bool        { return i.fi.IsDir }
+func (i fsInfo) Sys() interface{}   { return &i.fi }
+
+type fsFile struct {
+	info fs.FileInfo
+	*bytes.Reader
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fsFile) Close() error               { return nil }
+
+type fsDir struct {
+	sfs  *storeFS
+	name string
+	info fs.FileInfo
+	ents []fs.DirEntry // nil until read
+	off  int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: ErrIsDir}
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.ents == nil {
+		ents, err := d.sfs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.ents = ents
+	}
+	rest := d.ents[d.off:]
+	if n <= 0 {
+		d.off = len(d.ents)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.off += n
+	return rest[:n], nil
+}
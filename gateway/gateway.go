@@ -0,0 +1,155 @@
+//go:build go1.23
+
+// Package gateway serves a doozer store over HTTP.
+package gateway
+
+import (
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A Handler serves the store at a Client, such as a *doozer.Conn, over
+// HTTP.
+// The request path names a file or directory in the store.
+//
+//	GET <path>[?rev=N]
+//
+// replies with the file's body, or a listing of the directory, as of
+// revision N or the current revision. See ServeRead.
+//
+//	GET <path>?watch=1[&rev=N]
+//
+// streams every file beneath <path>, as of revision N or the current
+// revision, followed by every later change beneath <path>. See
+// ServeWatch.
+//
+//	GET <path>?events=1[&rev=N]
+//
+// streams every change beneath <path> as server-sent events, as does
+// any GET that accepts only text/event-stream. See ServeEvents.
+//
+//	GET <path> (with Upgrade: websocket)
+//
+// speaks the WebSocket protocol, sending every change beneath <path>
+// and answering commands to get and set files. See ServeSocket.
+//
+// HEAD is allowed only for reads; the streams have no end.
+type Handler struct {
+	// Writable, if set, allows WebSocket clients to set files.
+	Writable bool
+
+	c doozer.Client
+}
+
+// New returns a Handler serving the store at c.
+func New(c doozer.Client) *Handler {
+	return &Handler{c: c}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		h.ServeSocket(w, r)
+		return
+	}
+
+	if r.URL.Query().Get("watch") != "" {
+		h.ServeWatch(w, r)
+		return
+	}
+
+	if r.URL.Query().Get("events") != "" || r.Header.Get("Accept") == "text/event-stream" {
+		h.ServeEvents(w, r)
+		return
+	}
+
+	h.ServeRead(w, r)
+}
+
+// rev returns the rev query parameter of r, or the current
+// revision of the store if there is none.
+func (h *Handler) rev(r *http.Request) (int64, error) {
+	if s := r.URL.Query().Get("rev"); s != "" {
+		return strconv.ParseInt(s, 10, 64)
+	}
+	return h.c.Rev()
+}
+
+var contentTypes = map[string]string{
+	"json":     "application/x-ndjson",
+	"csv":      "text/csv; charset=utf-8",
+	"text":     "text/plain; charset=utf-8",
+	"protobuf": "application/octet-stream",
+}
+
+// formatter returns a Formatter for the format named by the format
+// query parameter of r, JSON by default, and sets the content type
+// of w to match. If the format is unknown, it replies with an error
+// and returns false.
+func formatter(w http.ResponseWriter, r *http.Request) (format.Formatter, bool) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "json"
+	}
+	f, err := format.New(name, w)
+	if err != nil {
+		http.Error(w, name+": "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	ct, ok := contentTypes[name]
+	if !ok {
+		ct = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ct)
+	return f, true
+}
+
+// subtree returns a glob matching everything beneath path,
+// cleaned first, so that "/a/" is the same as "/a".
+func subtree(path string) string {
+	path = doozer.CleanPath(path)
+	if path == "/" {
+		return "/**"
+	}
+	return path + "/**"
+}
+
+// streamMethod reports whether r may start a stream, which has no
+// end, and so no response a HEAD request could describe. If not, it
+// replies with an error.
+func streamMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+func errorStatus(err error) int {
+	if err == doozer.ErrNoEnt {
+		return http.StatusNotFound
+	}
+	if err, ok := err.(*doozer.Error); ok {
+		switch err.Err {
+		case doozer.ErrNoEnt:
+			return http.StatusNotFound
+		case doozer.ErrTooLate:
+			return http.StatusGone
+		case doozer.ErrAccess:
+			return http.StatusForbidden
+		}
+	}
+	if _, ok := err.(*strconv.NumError); ok {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,73 @@
+//go:build go1.23
+
+package gateway
+
+import (
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ServeRead replies with the file or directory at the request path,
+// as of the revision in the rev query parameter, or the current
+// revision if there is none.
+//
+// A file's body is sent as is. A directory is listed as a stream of
+// records, one per entry in order by name, in the format named by the
+// format query parameter, JSON lines by default; each record is of
+// type "file" or "dir", with the entry's path, rev, and len.
+//
+// The X-Doozer-Store-Rev header gives the revision read at, and, for
+// a file, the X-Doozer-Rev header gives the file's own revision.
+func (h *Handler) ServeRead(w http.ResponseWriter, r *http.Request) {
+	rev, err := h.rev(r)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	path := doozer.CleanPath(r.URL.Path)
+
+	fi, err := doozer.Statinfo(h.c, rev, path)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.Header().Set("X-Doozer-Store-Rev", strconv.FormatInt(rev, 10))
+
+	if !fi.IsDir {
+		body, frev, err := h.c.Get(path, &rev)
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		w.Header().Set("X-Doozer-Rev", strconv.FormatInt(frev, 10))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+		return
+	}
+
+	fis, err := doozer.Getdirinfo(h.c, path, rev, 0, -1)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name < fis[j].Name })
+
+	f, ok := formatter(w, r)
+	if !ok {
+		return
+	}
+	dir := path
+	if dir != "/" {
+		dir += "/"
+	}
+	for i := range fis {
+		if f.Write(format.FromFileInfo(dir+fis[i].Name, &fis[i])) != nil {
+			return
+		}
+	}
+	f.Flush()
+}
@@ -0,0 +1,184 @@
+//go:build go1.23
+
+package gateway
+
+import (
+	"errors"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrReadOnly  = errors.New("gateway is read-only")
+	ErrUnknownOp = errors.New("unknown op")
+	ErrNeedRev   = errors.New("set needs a rev")
+	ErrOutside   = errors.New("path outside request path")
+	ErrOrigin    = errors.New("origin does not match host")
+)
+
+// A socketCommand is a request sent by a WebSocket client.
+type socketCommand struct {
+	ID   int64  `json:"id"`
+	Op   string `json:"op"` // "get" or "set"
+	Path string `json:"path"`
+	Rev  *int64 `json:"rev,omitempty"`
+	Body []byte `json:"body,omitempty"`
+}
+
+// A socketMessage is sent to a WebSocket client: a change, or the
+// reply to a command.
+type socketMessage struct {
+	Type  string `json:"type"` // "set" or "del" for a change, else "reply" or "error"
+	ID    int64  `json:"id,omitempty"`
+	Rev   int64  `json:"rev"`
+	Path  string `json:"path,omitempty"`
+	Len   int    `json:"len,omitempty"`
+	Body  []byte `json:"body,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ServeSocket speaks the WebSocket protocol with a client, such as
+// an admin page, sending it each change beneath the request path and
+// answering its commands.
+//
+// Each message in either direction is a JSON object. A change is
+// sent as an object of type "set" or "del", with its rev, path, and
+// body in base64, starting at the revision in the rev query
+// parameter, or after the current revision if there is none. A
+// command is an object with an op, "get" or "set"; a path, taken
+// relative to the request path; for "get", the rev to read at, if
+// not the current one; and for "set", the rev the file must not have
+// been modified since, and the body. A "set" must carry a rev: 0 to
+// create the file, -1 to overwrite it whatever its rev. Its reply is
+// an object of type "reply", or "error" with the error, carrying the
+// command's id and the file's full path; a reply to "get" holds the
+// file's rev and body, and to "set" the file's new rev.
+//
+// Commands that set files fail unless h.Writable is set. A browser
+// may connect only from a page served by the same host, since it
+// sends the user's credentials with the request whatever page asks.
+func (h *Handler) ServeSocket(w http.ResponseWriter, r *http.Request) {
+	websocket.Server{
+		Handshake: checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serveSocket(ws, r)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// checkOrigin refuses a handshake whose Origin names a host other
+// than the one r was sent to. Clients other than browsers may send
+// no Origin at all.
+func checkOrigin(cfg *websocket.Config, r *http.Request) error {
+	var err error
+	cfg.Origin, err = websocket.Origin(cfg, r)
+	if err != nil {
+		return err
+	}
+	if cfg.Origin != nil && !strings.EqualFold(cfg.Origin.Host, r.Host) {
+		return ErrOrigin
+	}
+	return nil
+}
+
+func (h *Handler) serveSocket(ws *websocket.Conn, r *http.Request) {
+	defer ws.Close()
+	ctx := r.Context()
+
+	var rev int64
+	var err error
+	if r.URL.Query().Get("rev") != "" {
+		rev, err = h.rev(r)
+	} else {
+		rev, err = h.c.Rev()
+		rev++
+	}
+
+	var mu sync.Mutex
+	send := func(m socketMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return websocket.JSON.Send(ws, m)
+	}
+	if err != nil {
+		send(socketMessage{Type: "error", Error: err.Error()})
+		return
+	}
+
+	go func() {
+		for ev, err := range doozer.Events(ctx, h.c, subtree(r.URL.Path), rev) {
+			if err != nil {
+				send(socketMessage{Type: "error", Error: err.Error()})
+				ws.Close()
+				return
+			}
+			rec := format.FromEvent(ev)
+			m := socketMessage{Type: rec.Type, Rev: rec.Rev, Path: rec.Path, Len: rec.Len, Body: rec.Body}
+			if send(m) != nil {
+				return
+			}
+		}
+	}()
+
+	base := doozer.CleanPath(r.URL.Path)
+	for {
+		var cmd socketCommand
+		if err := websocket.JSON.Receive(ws, &cmd); err != nil {
+			return
+		}
+		m := h.command(base, &cmd)
+		m.ID = cmd.ID
+		if err := send(m); err != nil {
+			return
+		}
+	}
+}
+
+// command carries out cmd, whose path is relative to base, and
+// returns its reply.
+func (h *Handler) command(base string, cmd *socketCommand) socketMessage {
+	p, err := resolve(base, cmd.Path)
+	if err != nil {
+		return socketMessage{Type: "error", Error: err.Error()}
+	}
+
+	var m socketMessage
+	switch cmd.Op {
+	case "get":
+		m.Body, m.Rev, err = h.c.Get(p, cmd.Rev)
+		m.Len = len(m.Body)
+	case "set":
+		if !h.Writable {
+			err = ErrReadOnly
+			break
+		}
+		if cmd.Rev == nil {
+			err = ErrNeedRev
+			break
+		}
+		m.Rev, err = h.c.Set(p, *cmd.Rev, cmd.Body)
+	default:
+		err = ErrUnknownOp
+	}
+	if err != nil {
+		return socketMessage{Type: "error", Error: err.Error(), Path: p}
+	}
+	m.Type = "reply"
+	m.Path = p
+	return m
+}
+
+// resolve returns the path p names beneath base. It fails if the
+// result lies outside base.
+func resolve(base, p string) (string, error) {
+	p = path.Join(base, path.Clean("/"+p))
+	if p != base && !strings.HasPrefix(p, strings.TrimSuffix(base, "/")+"/") {
+		return "", &doozer.Error{Err: ErrOutside, Detail: p}
+	}
+	return p, nil
+}
@@ -0,0 +1,77 @@
+//go:build go1.23
+
+package gateway
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
+	"net/http"
+	"strconv"
+)
+
+// ServeEvents streams every change beneath the request path as
+// server-sent events, for consumption by a browser's EventSource or
+// any other SSE client.
+//
+// Each change is an event named "set" or "del" whose id is the
+// change's revision and whose data is the change as a JSON record,
+// as ServeWatch writes it. The stream starts after the revision in
+// the Last-Event-ID header, so a client that reconnects misses
+// nothing; failing that, at the revision in the rev query parameter;
+// failing that, after the current revision. It ends when the client
+// goes away or an error occurs.
+func (h *Handler) ServeEvents(w http.ResponseWriter, r *http.Request) {
+	if !streamMethod(w, r) {
+		return
+	}
+	ctx := r.Context()
+	glob := subtree(r.URL.Path)
+
+	var rev int64
+	var err error
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		rev, err = strconv.ParseInt(id, 10, 64)
+		rev++
+	} else if r.URL.Query().Get("rev") != "" {
+		rev, err = h.rev(r)
+	} else {
+		rev, err = h.c.Rev()
+		rev++
+	}
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	var buf bytes.Buffer
+	for ev, err := range doozer.Events(ctx, h.c, glob, rev) {
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err)
+			return
+		}
+		buf.Reset()
+		rec := format.FromEvent(ev)
+		f := format.JSON(&buf)
+		if f.Write(rec) != nil || f.Flush() != nil {
+			return
+		}
+		data := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		_, err = fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", rec.Type, ev.Rev, data)
+		if err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+//go:build go1.23
+
+package gateway
+
+import (
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/format"
+	"net/http"
+)
+
+// ServeWatch writes a snapshot of every file beneath the request
+// path, followed by every later change beneath it, so that a remote
+// consumer can fill a cache and then keep it current with a single
+// request.
+//
+// The output is a stream of records in the format named by the
+// format query parameter, JSON lines by default. Each file in the
+// snapshot is a record of type "file"; then comes a record of type
+// "sync" whose rev is the revision the snapshot was taken at; then
+// a record of type "set" or "del" for each change.
+//
+// The snapshot is taken at the revision in the rev query parameter,
+// or the current revision if there is none. The stream ends when the
+// client goes away or an error occurs.
+func (h *Handler) ServeWatch(w http.ResponseWriter, r *http.Request) {
+	if !streamMethod(w, r) {
+		return
+	}
+	ctx := r.Context()
+	glob := subtree(r.URL.Path)
+
+	rev, err := h.rev(r)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	f, ok := formatter(w, r)
+	if !ok {
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	emit := func(rec format.Record) bool {
+		if f.Write(rec) != nil || f.Flush() != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	started := false
+	for ev, err := range doozer.Files(ctx, h.c, glob, rev) {
+		if err != nil {
+			if !started {
+				http.Error(w, err.Error(), errorStatus(err))
+			}
+			return
+		}
+		started = true
+		rec := format.FromEvent(ev)
+		rec.Type = "file"
+		if !emit(rec) {
+			return
+		}
+	}
+	if !emit(format.Record{Type: "sync", Rev: rev}) {
+		return
+	}
+
+	for ev, err := range doozer.Events(ctx, h.c, glob, rev+1) {
+		if err != nil || !emit(format.FromEvent(ev)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package doozer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A Glob is a compiled glob pattern, as understood by Walk and Wait.
+//
+//	?  matches a single char in a single path component
+//	*  matches zero or more chars in a single path component
+//	** matches zero or more chars in zero or more components
+type Glob struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// CompileGlob parses a glob pattern, checking it with CheckGlob.
+func CompileGlob(pattern string) (*Glob, error) {
+	if err := CheckGlob(pattern); err != nil {
+		return nil, err
+	}
+	re, err := compileGlob(pattern)
+	if err != nil {
+		return nil, &Error{ErrBadPath, pattern}
+	}
+	return &Glob{pattern, re}, nil
+}
+
+// MustCompileGlob is like CompileGlob but panics if the pattern is
+// not well-formed.
+func MustCompileGlob(pattern string) *Glob {
+	g, err := CompileGlob(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// MatchGlob reports whether path matches the glob pattern.
+func MatchGlob(pattern, path string) (bool, error) {
+	g, err := CompileGlob(pattern)
+	if err != nil {
+		return false, err
+	}
+	return g.Match(path), nil
+}
+
+// Match reports whether path matches g.
+func (g *Glob) Match(path string) bool {
+	return g.re.MatchString(path)
+}
+
+// String returns the pattern g was compiled from.
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// compileGlob translates a doozer glob pattern to a regexp.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,540 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: doozer.proto
+
+package grpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          *string                `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Rev           *int64                 `protobuf:"varint,2,opt,name=rev" json:"rev,omitempty"` // store revision; the current one if not given
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_doozer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetRequest) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *GetRequest) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+type GetReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Body          []byte                 `protobuf:"bytes,1,opt,name=body" json:"body,omitempty"`
+	Rev           *int64                 `protobuf:"varint,2,opt,name=rev" json:"rev,omitempty"` // file revision; 0 if missing
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReply) Reset() {
+	*x = GetReply{}
+	mi := &file_doozer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReply) ProtoMessage() {}
+
+func (x *GetReply) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReply.ProtoReflect.Descriptor instead.
+func (*GetReply) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetReply) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *GetReply) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+type SetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          *string                `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Rev           *int64                 `protobuf:"varint,2,opt,name=rev" json:"rev,omitempty"` // 0: file must not exist; -1: any revision
+	Body          []byte                 `protobuf:"bytes,3,opt,name=body" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRequest) Reset() {
+	*x = SetRequest{}
+	mi := &file_doozer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRequest) ProtoMessage() {}
+
+func (x *SetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRequest.ProtoReflect.Descriptor instead.
+func (*SetRequest) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SetRequest) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *SetRequest) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+func (x *SetRequest) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+type SetReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rev           *int64                 `protobuf:"varint,1,opt,name=rev" json:"rev,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetReply) Reset() {
+	*x = SetReply{}
+	mi := &file_doozer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetReply) ProtoMessage() {}
+
+func (x *SetReply) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetReply.ProtoReflect.Descriptor instead.
+func (*SetReply) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SetReply) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+type DelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          *string                `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Rev           *int64                 `protobuf:"varint,2,opt,name=rev" json:"rev,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DelRequest) Reset() {
+	*x = DelRequest{}
+	mi := &file_doozer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelRequest) ProtoMessage() {}
+
+func (x *DelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelRequest.ProtoReflect.Descriptor instead.
+func (*DelRequest) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DelRequest) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *DelRequest) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+type DelReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DelReply) Reset() {
+	*x = DelReply{}
+	mi := &file_doozer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DelReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelReply) ProtoMessage() {}
+
+func (x *DelReply) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelReply.ProtoReflect.Descriptor instead.
+func (*DelReply) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{5}
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Glob          *string                `protobuf:"bytes,1,opt,name=glob" json:"glob,omitempty"`
+	Rev           *int64                 `protobuf:"varint,2,opt,name=rev" json:"rev,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_doozer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchRequest) GetGlob() string {
+	if x != nil && x.Glob != nil {
+		return *x.Glob
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rev           *int64                 `protobuf:"varint,1,opt,name=rev" json:"rev,omitempty"`
+	Path          *string                `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	Body          []byte                 `protobuf:"bytes,3,opt,name=body" json:"body,omitempty"`
+	Del           *bool                  `protobuf:"varint,4,opt,name=del" json:"del,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_doozer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_doozer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_doozer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Event) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+func (x *Event) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *Event) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Event) GetDel() bool {
+	if x != nil && x.Del != nil {
+		return *x.Del
+	}
+	return false
+}
+
+var File_doozer_proto protoreflect.FileDescriptor
+
+const file_doozer_proto_rawDesc = "" +
+	"\n" +
+	"\fdoozer.proto\x12\vdoozer.grpc\"2\n" +
+	"\n" +
+	"GetRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x10\n" +
+	"\x03rev\x18\x02 \x01(\x03R\x03rev\"0\n" +
+	"\bGetReply\x12\x12\n" +
+	"\x04body\x18\x01 \x01(\fR\x04body\x12\x10\n" +
+	"\x03rev\x18\x02 \x01(\x03R\x03rev\"F\n" +
+	"\n" +
+	"SetRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x10\n" +
+	"\x03rev\x18\x02 \x01(\x03R\x03rev\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\fR\x04body\"\x1c\n" +
+	"\bSetReply\x12\x10\n" +
+	"\x03rev\x18\x01 \x01(\x03R\x03rev\"2\n" +
+	"\n" +
+	"DelRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x10\n" +
+	"\x03rev\x18\x02 \x01(\x03R\x03rev\"\n" +
+	"\n" +
+	"\bDelReply\"4\n" +
+	"\fWatchRequest\x12\x12\n" +
+	"\x04glob\x18\x01 \x01(\tR\x04glob\x12\x10\n" +
+	"\x03rev\x18\x02 \x01(\x03R\x03rev\"S\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03rev\x18\x01 \x01(\x03R\x03rev\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\fR\x04body\x12\x10\n" +
+	"\x03del\x18\x04 \x01(\bR\x03del2\xe7\x01\n" +
+	"\x06Doozer\x125\n" +
+	"\x03Get\x12\x17.doozer.grpc.GetRequest\x1a\x15.doozer.grpc.GetReply\x125\n" +
+	"\x03Set\x12\x17.doozer.grpc.SetRequest\x1a\x15.doozer.grpc.SetReply\x125\n" +
+	"\x03Del\x12\x17.doozer.grpc.DelRequest\x1a\x15.doozer.grpc.DelReply\x128\n" +
+	"\x05Watch\x12\x19.doozer.grpc.WatchRequest\x1a\x12.doozer.grpc.Event0\x01B Z\x1egithub.com/dcjones/doozer/grpcb\x06proto2"
+
+var (
+	file_doozer_proto_rawDescOnce sync.Once
+	file_doozer_proto_rawDescData []byte
+)
+
+func file_doozer_proto_rawDescGZIP() []byte {
+	file_doozer_proto_rawDescOnce.Do(func() {
+		file_doozer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_doozer_proto_rawDesc), len(file_doozer_proto_rawDesc)))
+	})
+	return file_doozer_proto_rawDescData
+}
+
+var file_doozer_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_doozer_proto_goTypes = []any{
+	(*GetRequest)(nil),   // 0: doozer.grpc.GetRequest
+	(*GetReply)(nil),     // 1: doozer.grpc.GetReply
+	(*SetRequest)(nil),   // 2: doozer.grpc.SetRequest
+	(*SetReply)(nil),     // 3: doozer.grpc.SetReply
+	(*DelRequest)(nil),   // 4: doozer.grpc.DelRequest
+	(*DelReply)(nil),     // 5: doozer.grpc.DelReply
+	(*WatchRequest)(nil), // 6: doozer.grpc.WatchRequest
+	(*Event)(nil),        // 7: doozer.grpc.Event
+}
+var file_doozer_proto_depIdxs = []int32{
+	0, // 0: doozer.grpc.Doozer.Get:input_type -> doozer.grpc.GetRequest
+	2, // 1: doozer.grpc.Doozer.Set:input_type -> doozer.grpc.SetRequest
+	4, // 2: doozer.grpc.Doozer.Del:input_type -> doozer.grpc.DelRequest
+	6, // 3: doozer.grpc.Doozer.Watch:input_type -> doozer.grpc.WatchRequest
+	1, // 4: doozer.grpc.Doozer.Get:output_type -> doozer.grpc.GetReply
+	3, // 5: doozer.grpc.Doozer.Set:output_type -> doozer.grpc.SetReply
+	5, // 6: doozer.grpc.Doozer.Del:output_type -> doozer.grpc.DelReply
+	7, // 7: doozer.grpc.Doozer.Watch:output_type -> doozer.grpc.Event
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_doozer_proto_init() }
+func file_doozer_proto_init() {
+	if File_doozer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_doozer_proto_rawDesc), len(file_doozer_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_doozer_proto_goTypes,
+		DependencyIndexes: file_doozer_proto_depIdxs,
+		MessageInfos:      file_doozer_proto_msgTypes,
+	}.Build()
+	File_doozer_proto = out.File
+	file_doozer_proto_goTypes = nil
+	file_doozer_proto_depIdxs = nil
+}
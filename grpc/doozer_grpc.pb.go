@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: doozer.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Doozer_Get_FullMethodName   = "/doozer.grpc.Doozer/Get"
+	Doozer_Set_FullMethodName   = "/doozer.grpc.Doozer/Set"
+	Doozer_Del_FullMethodName   = "/doozer.grpc.Doozer/Del"
+	Doozer_Watch_FullMethodName = "/doozer.grpc.Doozer/Watch"
+)
+
+// DoozerClient is the client API for Doozer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Doozer proxies requests to a doozer store.
+type DoozerClient interface {
+	// Get returns the body and revision of a file.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetReply, error)
+	// Set writes a file, if it has not been modified since rev.
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetReply, error)
+	// Del deletes a file, if it has not been modified since rev.
+	Del(ctx context.Context, in *DelRequest, opts ...grpc.CallOption) (*DelReply, error)
+	// Watch streams every change to a file matching glob, starting
+	// at rev, or after the current revision if rev is not given.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type doozerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDoozerClient(cc grpc.ClientConnInterface) DoozerClient {
+	return &doozerClient{cc}
+}
+
+func (c *doozerClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReply)
+	err := c.cc.Invoke(ctx, Doozer_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doozerClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetReply)
+	err := c.cc.Invoke(ctx, Doozer_Set_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doozerClient) Del(ctx context.Context, in *DelRequest, opts ...grpc.CallOption) (*DelReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DelReply)
+	err := c.cc.Invoke(ctx, Doozer_Del_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *doozerClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Doozer_ServiceDesc.Streams[0], Doozer_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Doozer_WatchClient = grpc.ServerStreamingClient[Event]
+
+// DoozerServer is the server API for Doozer service.
+// All implementations must embed UnimplementedDoozerServer
+// for forward compatibility.
+//
+// Doozer proxies requests to a doozer store.
+type DoozerServer interface {
+	// Get returns the body and revision of a file.
+	Get(context.Context, *GetRequest) (*GetReply, error)
+	// Set writes a file, if it has not been modified since rev.
+	Set(context.Context, *SetRequest) (*SetReply, error)
+	// Del deletes a file, if it has not been modified since rev.
+	Del(context.Context, *DelRequest) (*DelReply, error)
+	// Watch streams every change to a file matching glob, starting
+	// at rev, or after the current revision if rev is not given.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedDoozerServer()
+}
+
+// UnimplementedDoozerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDoozerServer struct{}
+
+func (UnimplementedDoozerServer) Get(context.Context, *GetRequest) (*GetReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedDoozerServer) Set(context.Context, *SetRequest) (*SetReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (UnimplementedDoozerServer) Del(context.Context, *DelRequest) (*DelReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Del not implemented")
+}
+func (UnimplementedDoozerServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDoozerServer) mustEmbedUnimplementedDoozerServer() {}
+func (UnimplementedDoozerServer) testEmbeddedByValue()                {}
+
+// UnsafeDoozerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DoozerServer will
+// result in compilation errors.
+type UnsafeDoozerServer interface {
+	mustEmbedUnimplementedDoozerServer()
+}
+
+func RegisterDoozerServer(s grpc.ServiceRegistrar, srv DoozerServer) {
+	// If the following call pancis, it indicates UnimplementedDoozerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Doozer_ServiceDesc, srv)
+}
+
+func _Doozer_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoozerServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doozer_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoozerServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doozer_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoozerServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doozer_Set_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoozerServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doozer_Del_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DoozerServer).Del(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Doozer_Del_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DoozerServer).Del(ctx, req.(*DelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Doozer_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DoozerServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Doozer_WatchServer = grpc.ServerStreamingServer[Event]
+
+// Doozer_ServiceDesc is the grpc.ServiceDesc for Doozer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Doozer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "doozer.grpc.Doozer",
+	HandlerType: (*DoozerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _Doozer_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _Doozer_Set_Handler,
+		},
+		{
+			MethodName: "Del",
+			Handler:    _Doozer_Del_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Doozer_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "doozer.proto",
+}
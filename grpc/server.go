@@ -0,0 +1,137 @@
+// Package grpc serves a doozer store over gRPC, so that programs in
+// any language with a gRPC implementation can reach it. The service
+// is defined in doozer.proto.
+//
+// For example, with this package imported as doozergrpc:
+//
+//	s := grpc.NewServer()
+//	doozergrpc.RegisterDoozerServer(s, doozergrpc.NewServer(c))
+//	s.Serve(lis)
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative doozer.proto
+
+import (
+	"context"
+	"errors"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/internal/msg"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// A Server is a DoozerServer that proxies each call to a Conn.
+// The store is read and written with the call's context, so a call
+// that is cancelled or runs past its deadline stops waiting for it.
+type Server struct {
+	UnimplementedDoozerServer
+
+	c *doozer.Conn
+}
+
+// NewServer returns a Server proxying to c.
+func NewServer(c *doozer.Conn) *Server {
+	return &Server{c: c}
+}
+
+func (s *Server) Get(ctx context.Context, req *GetRequest) (*GetReply, error) {
+	body, rev, err := s.c.GetContext(ctx, req.GetPath(), req.Rev)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &GetReply{Body: body, Rev: &rev}, nil
+}
+
+func (s *Server) Set(ctx context.Context, req *SetRequest) (*SetReply, error) {
+	rev, err := s.c.SetContext(ctx, req.GetPath(), req.GetRev(), req.Body)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &SetReply{Rev: &rev}, nil
+}
+
+func (s *Server) Del(ctx context.Context, req *DelRequest) (*DelReply, error) {
+	err := s.c.DelContext(ctx, req.GetPath(), req.GetRev())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &DelReply{}, nil
+}
+
+func (s *Server) Watch(req *WatchRequest, stream Doozer_WatchServer) error {
+	ctx := stream.Context()
+	rev := req.GetRev()
+	if req.Rev == nil {
+		cur, err := s.c.RevContext(ctx)
+		if err != nil {
+			return toStatus(err)
+		}
+		rev = cur + 1
+	}
+
+	evs, cancel, err := s.c.Watch(req.GetGlob(), rev)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer cancel()
+
+	for {
+		select {
+		case ev, ok := <-evs:
+			if !ok {
+				return toStatus(cancel())
+			}
+			err := stream.Send(&Event{
+				Rev:  &ev.Rev,
+				Path: &ev.Path,
+				Body: ev.Body,
+				Del:  proto.Bool(ev.IsDel()),
+			})
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return toStatus(ctx.Err())
+		}
+	}
+}
+
+// toStatus converts err from the store to a gRPC status error.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codeOf(err), err.Error())
+}
+
+// codeOf returns the gRPC code for err, judged by the server's
+// response code or the client's error that an *doozer.Error holds.
+func codeOf(err error) codes.Code {
+	var e *doozer.Error
+	if errors.As(err, &e) {
+		err = e.Err
+	}
+	switch err {
+	case doozer.ErrNoEnt:
+		return codes.NotFound
+	case doozer.ErrOldRev:
+		return codes.FailedPrecondition
+	case doozer.ErrTooLate, doozer.ErrRange:
+		return codes.OutOfRange
+	case doozer.ErrAccess:
+		return codes.PermissionDenied
+	case msg.Response_BAD_PATH, msg.Response_MISSING_ARG,
+		doozer.ErrBadPath, doozer.ErrIsDir, doozer.ErrNotDir:
+		return codes.InvalidArgument
+	case msg.Response_UNKNOWN_VERB, doozer.ErrUnsupported:
+		return codes.Unimplemented
+	case doozer.ErrClosed, doozer.ErrReadonly:
+		return codes.Unavailable
+	case context.Canceled:
+		return codes.Canceled
+	case context.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,197 @@
+package doozer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrLagging = errors.New("server is behind its peers")
+)
+
+// Health is the result of a HealthChecker's probe.
+type Health struct {
+	Ready  bool
+	Rev    int64     // revision read by the last successful probe
+	LastOK time.Time // time of the last successful probe
+	Lag    int64     // revisions behind the furthest peer
+	Err    error     // why the connection is not ready
+
+	// Unreachable is the number of peers that failed to give their
+	// revision within Timeout. They are left out of Lag.
+	Unreachable int
+}
+
+// A HealthChecker reports whether a Conn is ready for use, for
+// orchestrators that route traffic only to ready instances. It
+// probes the server, when asked, by reading the current revision,
+// reusing a recent result, so that frequent checks cost little.
+//
+// The Conn is ready if it is open and the probe succeeds within
+// Timeout. If Peers are given, such as connections to other members
+// of the cluster, the server must also be no more than MaxLag
+// revisions behind the furthest of them. Peers are asked at once,
+// and a peer that fails or doesn't answer within Timeout is counted
+// as unreachable.
+type HealthChecker struct {
+	// MaxAge is how long a probe's result is reused.
+	// Zero means one second.
+	MaxAge time.Duration
+
+	// Timeout bounds each probe. Zero means two seconds.
+	Timeout time.Duration
+
+	Peers  []Client
+	MaxLag int64
+
+	c *Conn
+
+	mu      sync.Mutex
+	last    Health
+	at      time.Time // time the last probe finished
+	probing chan bool // closed when the probe in progress finishes
+}
+
+// NewHealthChecker returns a HealthChecker for c.
+func NewHealthChecker(c *Conn) *HealthChecker {
+	return &HealthChecker{c: c}
+}
+
+// Check returns the connection's health, probing the server if the
+// last probe is too old. Calls made while a probe is in progress
+// wait for its result rather than probing again.
+func (h *HealthChecker) Check() Health {
+	h.mu.Lock()
+	maxAge := h.MaxAge
+	if maxAge == 0 {
+		maxAge = time.Second
+	}
+	if !h.at.IsZero() && time.Since(h.at) < maxAge {
+		defer h.mu.Unlock()
+		return h.last
+	}
+
+	if done := h.probing; done != nil {
+		h.mu.Unlock()
+		<-done
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.last
+	}
+
+	done := make(chan bool)
+	h.probing = done
+	last := h.last
+	h.mu.Unlock()
+
+	hl := h.probe(last)
+
+	h.mu.Lock()
+	h.last, h.at, h.probing = hl, time.Now(), nil
+	h.mu.Unlock()
+	close(done)
+	return hl
+}
+
+// probe checks the server, and its peers, without holding h.mu.
+// last is the result of the previous probe.
+func (h *HealthChecker) probe(last Health) Health {
+	hl := Health{Rev: last.Rev, LastOK: last.LastOK}
+
+	h.c.mu.Lock()
+	closed := h.c.closed
+	h.c.mu.Unlock()
+	if closed {
+		hl.Err = ErrClosed
+		return hl
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	rev, err := h.c.rev(ctx)
+	if err != nil {
+		hl.Err = err
+		return hl
+	}
+	hl.Rev = rev
+	hl.LastOK = time.Now()
+
+	for _, r := range peerRevs(ctx, h.Peers) {
+		if r.err != nil {
+			hl.Unreachable++
+			continue
+		}
+		if r.rev-rev > hl.Lag {
+			hl.Lag = r.rev - rev
+		}
+	}
+	if len(h.Peers) > 0 && hl.Lag > h.MaxLag {
+		hl.Err = ErrLagging
+		return hl
+	}
+
+	hl.Ready = true
+	return hl
+}
+
+type peerRev struct {
+	i   int
+	rev int64
+	err error
+}
+
+// peerRevs asks each of peers for its revision at once. A peer that
+// hasn't answered when ctx is done gets ctx's error.
+func peerRevs(ctx context.Context, peers []Client) []peerRev {
+	ch := make(chan peerRev, len(peers))
+	for i, p := range peers {
+		go func(i int, p Client) {
+			r := peerRev{i: i}
+			if c, ok := p.(*Conn); ok {
+				r.rev, r.err = c.rev(ctx)
+			} else {
+				r.rev, r.err = p.Rev()
+			}
+			ch <- r
+		}(i, p)
+	}
+
+	revs := make([]peerRev, len(peers))
+	for i := range revs {
+		revs[i] = peerRev{i: i, err: context.DeadlineExceeded}
+	}
+	for n := 0; n < len(peers); n++ {
+		select {
+		case r := <-ch:
+			revs[r.i] = r
+		case <-ctx.Done():
+			return revs
+		}
+	}
+	return revs
+}
+
+// ServeHTTP answers a readiness probe: 200 OK if the connection is
+// ready, or 503 Service Unavailable with the reason if not.
+func (h *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hl := h.Check()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !hl.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "not ready: %v\n", hl.Err)
+		return
+	}
+	if hl.Unreachable > 0 {
+		fmt.Fprintf(w, "ok rev %d, %d peers unreachable\n", hl.Rev, hl.Unreachable)
+		return
+	}
+	fmt.Fprintf(w, "ok rev %d\n", hl.Rev)
+}
@@ -0,0 +1,197 @@
+package doozer
+
+import (
+	"sync"
+	"time"
+)
+
+// HistoryStats is a sample taken by a HistoryMonitor.
+type HistoryStats struct {
+	Time       time.Time
+	Rev        int64         // current revision of the store
+	Oldest     int64         // oldest revision the server still retains
+	Checkpoint int64         // last revision the consumer has processed
+	Lag        int64         // Rev - Checkpoint
+	Rate       float64       // revisions per second since the previous sample
+	Headroom   time.Duration // estimated time until Checkpoint is discarded
+	TooLate    bool          // whether Checkpoint has already been discarded
+}
+
+// A HistoryMonitor periodically compares a consumer's checkpoint with
+// the history the server retains, so that a consumer falling behind
+// is noticed before its next Wait or Walk fails with ErrTooLate.
+//
+// The server discards old revisions as new ones are made, so the
+// retained window advances at the rate the store changes. Headroom
+// is how long the window will take, at the current rate, to pass the
+// checkpoint.
+type HistoryMonitor struct {
+	// Interval is the time between samples. Zero means 10 seconds.
+	Interval time.Duration
+
+	// Warn is the headroom below which OnWarn is called.
+	// Zero means one minute.
+	Warn time.Duration
+
+	// OnSample, if set, is called with every sample.
+	OnSample func(HistoryStats)
+
+	// OnWarn is called with each sample whose headroom is below Warn,
+	// or whose checkpoint has already been discarded. If nil, a
+	// warning is given to the Logger of the monitored Conn.
+	OnWarn func(HistoryStats)
+
+	c          Client
+	checkpoint func() int64
+
+	mu   sync.Mutex
+	last HistoryStats
+	err  error // from the most recent sample
+	stop chan bool
+}
+
+// NewHistoryMonitor returns a monitor of the history of c, relative
+// to the revision returned by checkpoint.
+func NewHistoryMonitor(c Client, checkpoint func() int64) *HistoryMonitor {
+	return &HistoryMonitor{c: c, checkpoint: checkpoint}
+}
+
+// Start begins sampling in a new goroutine.
+func (m *HistoryMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan bool)
+	go m.run(m.stop)
+}
+
+// Stop ends sampling.
+func (m *HistoryMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+// Err returns the error from the most recent sample, if it failed.
+// A monitor started with Start also gives each such error to the
+// Logger of the monitored Conn.
+func (m *HistoryMonitor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// Stats returns the most recent sample.
+func (m *HistoryMonitor) Stats() HistoryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+func (m *HistoryMonitor) run(stop chan bool) {
+	d := m.Interval
+	if d <= 0 {
+		d = 10 * time.Second
+	}
+	tick := time.NewTicker(d)
+	defer tick.Stop()
+
+	for {
+		_, err := m.Sample()
+		if err != nil {
+			loggerOf(m.c).Warn("doozer: history sample failed", "err", err)
+		}
+		select {
+		case <-tick.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Sample takes a sample now, reporting it as the monitor's
+// callbacks direct.
+func (m *HistoryMonitor) Sample() (HistoryStats, error) {
+	s, err := m.sample()
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+	return s, err
+}
+
+func (m *HistoryMonitor) sample() (HistoryStats, error) {
+	var s HistoryStats
+	var err error
+
+	s.Time = time.Now()
+	s.Checkpoint = m.checkpoint()
+	s.Rev, err = m.c.Rev()
+	if err != nil {
+		return s, err
+	}
+
+	m.mu.Lock()
+	prev := m.last
+	m.mu.Unlock()
+
+	s.Oldest, err = m.oldest(prev.Oldest, s.Rev)
+	if err != nil {
+		return s, err
+	}
+
+	s.Lag = s.Rev - s.Checkpoint
+	s.TooLate = s.Checkpoint < s.Oldest
+	if !prev.Time.IsZero() && s.Rev > prev.Rev {
+		s.Rate = float64(s.Rev-prev.Rev) / s.Time.Sub(prev.Time).Seconds()
+	}
+	if !s.TooLate && s.Rate > 0 {
+		left := float64(s.Checkpoint - s.Oldest)
+		s.Headroom = time.Duration(left / s.Rate * float64(time.Second))
+	}
+
+	m.mu.Lock()
+	m.last = s
+	m.mu.Unlock()
+
+	if m.OnSample != nil {
+		m.OnSample(s)
+	}
+	warn := m.Warn
+	if warn <= 0 {
+		warn = time.Minute
+	}
+	if s.TooLate || s.Rate > 0 && s.Headroom < warn {
+		if m.OnWarn != nil {
+			m.OnWarn(s)
+		} else {
+			loggerOf(m.c).Warn("doozer: consumer falling behind history",
+				"checkpoint", s.Checkpoint, "lag", s.Lag,
+				"headroom", s.Headroom, "toolate", s.TooLate)
+		}
+	}
+	return s, nil
+}
+
+// oldest finds the oldest revision the server retains,
+// searching between lo, a revision once retained, and rev.
+func (m *HistoryMonitor) oldest(lo, rev int64) (int64, error) {
+	hi := rev
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		_, _, err := m.c.Stat("/", &mid)
+		if err, ok := err.(*Error); ok && err.Err == ErrTooLate {
+			lo = mid + 1
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		hi = mid
+	}
+	return lo, nil
+}
@@ -0,0 +1,138 @@
+package doozer
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+)
+
+var (
+	ErrSlowSubscriber = errors.New("subscriber fell behind")
+)
+
+// A Hub shares one Watch on a broad glob among many subscribers,
+// each of which receives the events matching its own, narrower glob.
+// Where many parts of a program each wait for changes beneath a
+// common directory, a Hub keeps one server-side WAIT for all of
+// them instead of one for each.
+//
+// Events are delivered to each subscriber in order. A subscriber that
+// lets Buffer events go unread is dropped, so that it cannot hold up
+// the others; its channel is closed and its Err is ErrSlowSubscriber.
+type Hub struct {
+	// Buffer is the number of events held for each subscriber.
+	// Zero means 64. It applies to subscriptions made after it is set.
+	Buffer int
+
+	cancel CancelFunc
+
+	mu   sync.Mutex
+	subs map[*Subscription]bool
+	err  error
+	done bool
+}
+
+// A Subscription receives events from a Hub on C.
+type Subscription struct {
+	C <-chan Event
+
+	h      *Hub
+	c      chan Event
+	re     *regexp.Regexp
+	filter func(Event) bool
+	err    error
+}
+
+// NewHub starts watching for changes, on or after rev, to files
+// matching glob, to be shared by subscribers.
+func NewHub(c *Conn, glob string, rev int64) (*Hub, error) {
+	evs, cancel, err := c.Watch(glob, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hub{cancel: cancel, subs: make(map[*Subscription]bool)}
+	go h.run(evs)
+	return h, nil
+}
+
+// Subscribe returns a subscription to the events matching glob, and
+// filter if it is not nil, from now on. The glob should select files
+// within the hub's glob; others are never seen.
+func (h *Hub) Subscribe(glob string, filter func(Event) bool) (*Subscription, error) {
+	re, err := compileGlob(glob)
+	if err != nil {
+		return nil, err
+	}
+
+	n := h.Buffer
+	if n <= 0 {
+		n = 64
+	}
+	ch := make(chan Event, n)
+	s := &Subscription{C: ch, h: h, c: ch, re: re, filter: filter}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.done {
+		close(ch)
+		s.err = h.err
+		return s, nil
+	}
+	h.subs[s] = true
+	return s, nil
+}
+
+// Close ends the subscription and closes its channel.
+func (s *Subscription) Close() {
+	h := s.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[s] {
+		delete(h.subs, s)
+		close(s.c)
+	}
+}
+
+// Err returns the error that ended the subscription, if any:
+// ErrSlowSubscriber, or the error that ended the hub's Watch.
+func (s *Subscription) Err() error {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+	return s.err
+}
+
+// Close stops the hub's Watch and ends every subscription.
+func (h *Hub) Close() {
+	h.cancel()
+}
+
+func (h *Hub) run(evs <-chan Event) {
+	for ev := range evs {
+		h.mu.Lock()
+		for s := range h.subs {
+			if !s.re.MatchString(ev.Path) || s.filter != nil && !s.filter(ev) {
+				continue
+			}
+			select {
+			case s.c <- ev:
+			default:
+				s.err = ErrSlowSubscriber
+				delete(h.subs, s)
+				close(s.c)
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	err := h.cancel()
+	h.mu.Lock()
+	h.err = err
+	h.done = true
+	for s := range h.subs {
+		s.err = err
+		close(s.c)
+	}
+	h.subs = nil
+	h.mu.Unlock()
+}
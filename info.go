@@ -0,0 +1,121 @@
+package doozer
+
+import (
+	"errors"
+	"net"
+)
+
+var (
+	ErrNoSelf = errors.New("server not found in /ctl/node")
+)
+
+// ClusterInfo describes a cluster as seen by one of its servers.
+type ClusterInfo struct {
+	Rev     int64  // revision the information was read at
+	Self    *Node  // the server the Conn is connected to
+	Nodes   []Node // every node, in order of ID
+	Members []Node // nodes holding a calendar slot, in order of slot
+}
+
+// Self returns the node for the server c is connected to,
+// whose identity and version it keeps under /ctl/node.
+func (c *Conn) Self() (*Node, error) {
+	rev, err := c.Rev()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := Nodes(c, rev)
+	if err != nil {
+		return nil, err
+	}
+	return c.self(nodes)
+}
+
+// Version returns the version of the server c is connected to.
+func (c *Conn) Version() (string, error) {
+	n, err := c.Self()
+	if err != nil {
+		return "", err
+	}
+	return n.Version, nil
+}
+
+// ClusterInfo returns the nodes of the cluster and its members, as
+// listed in the calendar, along with the server c is connected to.
+func (c *Conn) ClusterInfo() (*ClusterInfo, error) {
+	rev, err := c.Rev()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := Nodes(c, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := CalSlots(c, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	ci := &ClusterInfo{Rev: rev, Nodes: nodes}
+	for _, s := range slots {
+		for _, n := range nodes {
+			if n.ID == s.Node {
+				ci.Members = append(ci.Members, n)
+				break
+			}
+		}
+	}
+
+	ci.Self, err = c.self(nodes)
+	if err != nil {
+		return nil, err
+	}
+	return ci, nil
+}
+
+// self finds, among nodes, the one whose address is the one c
+// dialed or the one its link is connected to.
+func (c *Conn) self(nodes []Node) (*Node, error) {
+	c.mu.Lock()
+	remote := c.l.conn.RemoteAddr().String()
+	c.mu.Unlock()
+
+	for i := range nodes {
+		a := nodes[i].Addr
+		if a == "" {
+			continue
+		}
+		if a == c.addr || a == remote || sameAddr(a, remote) {
+			return &nodes[i], nil
+		}
+	}
+	return nil, &Error{ErrNoSelf, remote}
+}
+
+// sameAddr reports whether a, which may name a host, and remote,
+// an IP address and port, are the same address.
+func sameAddr(a, remote string) bool {
+	host, port, err := net.SplitHostPort(a)
+	if err != nil {
+		return false
+	}
+	rhost, rport, err := net.SplitHostPort(remote)
+	if err != nil || port != rport {
+		return false
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return false
+	}
+	rip := net.ParseIP(rhost)
+	for _, ip := range ips {
+		if ip.Equal(rip) {
+			return true
+		}
+	}
+	return false
+}
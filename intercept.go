@@ -0,0 +1,122 @@
+package doozer
+
+import (
+	"context"
+	"google.golang.org/protobuf/proto"
+)
+
+// A Request is a request to the server, as seen by an Interceptor.
+type Request struct {
+	Verb   string // such as "GET" or "WAIT"
+	Path   string
+	Value  []byte
+	Rev    *int64 // nil means none was given
+	Offset int
+}
+
+// A Response is the server's reply to a Request. Errors reported by
+// the server are returned as an *Error alongside a nil Response.
+type Response struct {
+	Rev   int64
+	Path  string
+	Value []byte
+	Len   int
+	Flags int32
+}
+
+// An Invoker sends req and returns the response.
+type Invoker func(ctx context.Context, req *Request) (*Response, error)
+
+// An Interceptor is called for every request made on a Conn, in
+// place of sending it. It may inspect or modify req, call next to
+// carry on sending it, and inspect or replace the result. It may
+// also answer the request itself, or call next more than once.
+type Interceptor func(ctx context.Context, req *Request, next Invoker) (*Response, error)
+
+// Use adds interceptors to c, to be applied to every later request.
+// Interceptors added earlier are outermost: they see each request
+// first and each response last.
+func (c *Conn) Use(is ...Interceptor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Copy, so that a chain being run is never modified.
+	c.interceptors = append(c.interceptors[:len(c.interceptors):len(c.interceptors)], is...)
+}
+
+func (c *Conn) intercept(ctx context.Context, t *txn, is []Interceptor) error {
+	next := Invoker(func(ctx context.Context, req *Request) (*Response, error) {
+		var u txn
+		req.request(&u.req)
+		err := c.retry(ctx, &u)
+		if err != nil {
+			return nil, err
+		}
+		return exportResponse(u.resp), nil
+	})
+	for i := len(is) - 1; i >= 0; i-- {
+		f, inner := is[i], next
+		next = func(ctx context.Context, req *Request) (*Response, error) {
+			return f(ctx, req, inner)
+		}
+	}
+
+	resp, err := next(ctx, exportRequest(&t.req))
+	if err != nil {
+		return err
+	}
+	t.resp = resp.response()
+	return nil
+}
+
+func exportRequest(r *request) *Request {
+	req := &Request{
+		Verb:  request_Verb_name[int32(*r.Verb)],
+		Path:  r.GetPath(),
+		Value: r.Value,
+		Rev:   r.Rev,
+	}
+	if r.Offset != nil {
+		req.Offset = int(*r.Offset)
+	}
+	return req
+}
+
+func (req *Request) request(r *request) {
+	v := request_Verb(request_Verb_value[req.Verb])
+	r.Verb = v.Enum()
+	if req.Path != "" {
+		r.Path = proto.String(req.Path)
+	}
+	r.Value = req.Value
+	r.Rev = req.Rev
+	if v == request_GETDIR || v == request_WALK {
+		r.Offset = proto.Int32(int32(req.Offset))
+	}
+}
+
+func exportResponse(r *response) *Response {
+	resp := &Response{
+		Path:  r.GetPath(),
+		Value: r.Value,
+	}
+	if r.Rev != nil {
+		resp.Rev = *r.Rev
+	}
+	if r.Len != nil {
+		resp.Len = int(*r.Len)
+	}
+	if r.Flags != nil {
+		resp.Flags = *r.Flags
+	}
+	return resp
+}
+
+func (resp *Response) response() *response {
+	return &response{
+		Rev:   proto.Int64(resp.Rev),
+		Path:  proto.String(resp.Path),
+		Value: resp.Value,
+		Len:   proto.Int32(int32(resp.Len)),
+		Flags: proto.Int32(resp.Flags),
+	}
+}
@@ -0,0 +1,11 @@
+// Package msg holds the messages exchanged with a doozer server,
+// generated from msg.proto.
+package msg
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative msg.proto
+
+// Error returns the name of the error code,
+// so that server errors can be compared with ==.
+func (x Response_Err) Error() string {
+	return x.String()
+}
@@ -0,0 +1,496 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: msg.proto
+
+package msg
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Request_Verb int32
+
+const (
+	Request_GET    Request_Verb = 1
+	Request_SET    Request_Verb = 2
+	Request_DEL    Request_Verb = 3
+	Request_REV    Request_Verb = 5
+	Request_WAIT   Request_Verb = 6
+	Request_NOP    Request_Verb = 7
+	Request_WALK   Request_Verb = 9
+	Request_CANCEL Request_Verb = 10
+	Request_GETDIR Request_Verb = 14
+	Request_STAT   Request_Verb = 16
+	Request_ACCESS Request_Verb = 99
+)
+
+// Enum value maps for Request_Verb.
+var (
+	Request_Verb_name = map[int32]string{
+		1:  "GET",
+		2:  "SET",
+		3:  "DEL",
+		5:  "REV",
+		6:  "WAIT",
+		7:  "NOP",
+		9:  "WALK",
+		10: "CANCEL",
+		14: "GETDIR",
+		16: "STAT",
+		99: "ACCESS",
+	}
+	Request_Verb_value = map[string]int32{
+		"GET":    1,
+		"SET":    2,
+		"DEL":    3,
+		"REV":    5,
+		"WAIT":   6,
+		"NOP":    7,
+		"WALK":   9,
+		"CANCEL": 10,
+		"GETDIR": 14,
+		"STAT":   16,
+		"ACCESS": 99,
+	}
+)
+
+func (x Request_Verb) Enum() *Request_Verb {
+	p := new(Request_Verb)
+	*p = x
+	return p
+}
+
+func (x Request_Verb) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Request_Verb) Descriptor() protoreflect.EnumDescriptor {
+	return file_msg_proto_enumTypes[0].Descriptor()
+}
+
+func (Request_Verb) Type() protoreflect.EnumType {
+	return &file_msg_proto_enumTypes[0]
+}
+
+func (x Request_Verb) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Request_Verb) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Request_Verb(num)
+	return nil
+}
+
+// Deprecated: Use Request_Verb.Descriptor instead.
+func (Request_Verb) EnumDescriptor() ([]byte, []int) {
+	return file_msg_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type Response_Err int32
+
+const (
+	// don't use value 0
+	Response_OTHER        Response_Err = 127
+	Response_TAG_IN_USE   Response_Err = 1
+	Response_UNKNOWN_VERB Response_Err = 2
+	Response_READONLY     Response_Err = 3
+	Response_TOO_LATE     Response_Err = 4
+	Response_REV_MISMATCH Response_Err = 5
+	Response_BAD_PATH     Response_Err = 6
+	Response_MISSING_ARG  Response_Err = 7
+	Response_RANGE        Response_Err = 8
+	Response_NOTDIR       Response_Err = 20
+	Response_ISDIR        Response_Err = 21
+	Response_NOENT        Response_Err = 22
+)
+
+// Enum value maps for Response_Err.
+var (
+	Response_Err_name = map[int32]string{
+		127: "OTHER",
+		1:   "TAG_IN_USE",
+		2:   "UNKNOWN_VERB",
+		3:   "READONLY",
+		4:   "TOO_LATE",
+		5:   "REV_MISMATCH",
+		6:   "BAD_PATH",
+		7:   "MISSING_ARG",
+		8:   "RANGE",
+		20:  "NOTDIR",
+		21:  "ISDIR",
+		22:  "NOENT",
+	}
+	Response_Err_value = map[string]int32{
+		"OTHER":        127,
+		"TAG_IN_USE":   1,
+		"UNKNOWN_VERB": 2,
+		"READONLY":     3,
+		"TOO_LATE":     4,
+		"REV_MISMATCH": 5,
+		"BAD_PATH":     6,
+		"MISSING_ARG":  7,
+		"RANGE":        8,
+		"NOTDIR":       20,
+		"ISDIR":        21,
+		"NOENT":        22,
+	}
+)
+
+func (x Response_Err) Enum() *Response_Err {
+	p := new(Response_Err)
+	*p = x
+	return p
+}
+
+func (x Response_Err) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Response_Err) Descriptor() protoreflect.EnumDescriptor {
+	return file_msg_proto_enumTypes[1].Descriptor()
+}
+
+func (Response_Err) Type() protoreflect.EnumType {
+	return &file_msg_proto_enumTypes[1]
+}
+
+func (x Response_Err) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Response_Err) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Response_Err(num)
+	return nil
+}
+
+// Deprecated: Use Response_Err.Descriptor instead.
+func (Response_Err) EnumDescriptor() ([]byte, []int) {
+	return file_msg_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// see doc/proto.md
+type Request struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           *int32                 `protobuf:"varint,1,opt,name=tag" json:"tag,omitempty"`
+	Verb          *Request_Verb          `protobuf:"varint,2,opt,name=verb,enum=doozer.Request_Verb" json:"verb,omitempty"`
+	Path          *string                `protobuf:"bytes,4,opt,name=path" json:"path,omitempty"`
+	Value         []byte                 `protobuf:"bytes,5,opt,name=value" json:"value,omitempty"`
+	OtherTag      *int32                 `protobuf:"varint,6,opt,name=other_tag,json=otherTag" json:"other_tag,omitempty"`
+	Offset        *int32                 `protobuf:"varint,7,opt,name=offset" json:"offset,omitempty"`
+	Rev           *int64                 `protobuf:"varint,9,opt,name=rev" json:"rev,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_msg_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_msg_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_msg_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Request) GetTag() int32 {
+	if x != nil && x.Tag != nil {
+		return *x.Tag
+	}
+	return 0
+}
+
+func (x *Request) GetVerb() Request_Verb {
+	if x != nil && x.Verb != nil {
+		return *x.Verb
+	}
+	return Request_GET
+}
+
+func (x *Request) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *Request) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Request) GetOtherTag() int32 {
+	if x != nil && x.OtherTag != nil {
+		return *x.OtherTag
+	}
+	return 0
+}
+
+func (x *Request) GetOffset() int32 {
+	if x != nil && x.Offset != nil {
+		return *x.Offset
+	}
+	return 0
+}
+
+func (x *Request) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+// see doc/proto.md
+type Response struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           *int32                 `protobuf:"varint,1,opt,name=tag" json:"tag,omitempty"`
+	Flags         *int32                 `protobuf:"varint,2,opt,name=flags" json:"flags,omitempty"`
+	Rev           *int64                 `protobuf:"varint,3,opt,name=rev" json:"rev,omitempty"`
+	Path          *string                `protobuf:"bytes,5,opt,name=path" json:"path,omitempty"`
+	Value         []byte                 `protobuf:"bytes,6,opt,name=value" json:"value,omitempty"`
+	Len           *int32                 `protobuf:"varint,8,opt,name=len" json:"len,omitempty"`
+	ErrCode       *Response_Err          `protobuf:"varint,100,opt,name=err_code,json=errCode,enum=doozer.Response_Err" json:"err_code,omitempty"`
+	ErrDetail     *string                `protobuf:"bytes,101,opt,name=err_detail,json=errDetail" json:"err_detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_msg_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_msg_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_msg_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Response) GetTag() int32 {
+	if x != nil && x.Tag != nil {
+		return *x.Tag
+	}
+	return 0
+}
+
+func (x *Response) GetFlags() int32 {
+	if x != nil && x.Flags != nil {
+		return *x.Flags
+	}
+	return 0
+}
+
+func (x *Response) GetRev() int64 {
+	if x != nil && x.Rev != nil {
+		return *x.Rev
+	}
+	return 0
+}
+
+func (x *Response) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *Response) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Response) GetLen() int32 {
+	if x != nil && x.Len != nil {
+		return *x.Len
+	}
+	return 0
+}
+
+func (x *Response) GetErrCode() Response_Err {
+	if x != nil && x.ErrCode != nil {
+		return *x.ErrCode
+	}
+	return Response_OTHER
+}
+
+func (x *Response) GetErrDetail() string {
+	if x != nil && x.ErrDetail != nil {
+		return *x.ErrDetail
+	}
+	return ""
+}
+
+var File_msg_proto protoreflect.FileDescriptor
+
+const file_msg_proto_rawDesc = "" +
+	"\n" +
+	"\tmsg.proto\x12\x06doozer\"\xad\x02\n" +
+	"\aRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\x05R\x03tag\x12(\n" +
+	"\x04verb\x18\x02 \x01(\x0e2\x14.doozer.Request.VerbR\x04verb\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\fR\x05value\x12\x1b\n" +
+	"\tother_tag\x18\x06 \x01(\x05R\botherTag\x12\x16\n" +
+	"\x06offset\x18\a \x01(\x05R\x06offset\x12\x10\n" +
+	"\x03rev\x18\t \x01(\x03R\x03rev\"u\n" +
+	"\x04Verb\x12\a\n" +
+	"\x03GET\x10\x01\x12\a\n" +
+	"\x03SET\x10\x02\x12\a\n" +
+	"\x03DEL\x10\x03\x12\a\n" +
+	"\x03REV\x10\x05\x12\b\n" +
+	"\x04WAIT\x10\x06\x12\a\n" +
+	"\x03NOP\x10\a\x12\b\n" +
+	"\x04WALK\x10\t\x12\n" +
+	"\n" +
+	"\x06CANCEL\x10\n" +
+	"\x12\n" +
+	"\n" +
+	"\x06GETDIR\x10\x0e\x12\b\n" +
+	"\x04STAT\x10\x10\x12\n" +
+	"\n" +
+	"\x06ACCESS\x10c\"\xff\x02\n" +
+	"\bResponse\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\x05R\x03tag\x12\x14\n" +
+	"\x05flags\x18\x02 \x01(\x05R\x05flags\x12\x10\n" +
+	"\x03rev\x18\x03 \x01(\x03R\x03rev\x12\x12\n" +
+	"\x04path\x18\x05 \x01(\tR\x04path\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\fR\x05value\x12\x10\n" +
+	"\x03len\x18\b \x01(\x05R\x03len\x12/\n" +
+	"\berr_code\x18d \x01(\x0e2\x14.doozer.Response.ErrR\aerrCode\x12\x1d\n" +
+	"\n" +
+	"err_detail\x18e \x01(\tR\terrDetail\"\xac\x01\n" +
+	"\x03Err\x12\t\n" +
+	"\x05OTHER\x10\x7f\x12\x0e\n" +
+	"\n" +
+	"TAG_IN_USE\x10\x01\x12\x10\n" +
+	"\fUNKNOWN_VERB\x10\x02\x12\f\n" +
+	"\bREADONLY\x10\x03\x12\f\n" +
+	"\bTOO_LATE\x10\x04\x12\x10\n" +
+	"\fREV_MISMATCH\x10\x05\x12\f\n" +
+	"\bBAD_PATH\x10\x06\x12\x0f\n" +
+	"\vMISSING_ARG\x10\a\x12\t\n" +
+	"\x05RANGE\x10\b\x12\n" +
+	"\n" +
+	"\x06NOTDIR\x10\x14\x12\t\n" +
+	"\x05ISDIR\x10\x15\x12\t\n" +
+	"\x05NOENT\x10\x16B(Z&github.com/dcjones/doozer/internal/msg"
+
+var (
+	file_msg_proto_rawDescOnce sync.Once
+	file_msg_proto_rawDescData []byte
+)
+
+func file_msg_proto_rawDescGZIP() []byte {
+	file_msg_proto_rawDescOnce.Do(func() {
+		file_msg_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_msg_proto_rawDesc), len(file_msg_proto_rawDesc)))
+	})
+	return file_msg_proto_rawDescData
+}
+
+var file_msg_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_msg_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_msg_proto_goTypes = []any{
+	(Request_Verb)(0), // 0: doozer.Request.Verb
+	(Response_Err)(0), // 1: doozer.Response.Err
+	(*Request)(nil),   // 2: doozer.Request
+	(*Response)(nil),  // 3: doozer.Response
+}
+var file_msg_proto_depIdxs = []int32{
+	0, // 0: doozer.Request.verb:type_name -> doozer.Request.Verb
+	1, // 1: doozer.Response.err_code:type_name -> doozer.Response.Err
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_msg_proto_init() }
+func file_msg_proto_init() {
+	if File_msg_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_msg_proto_rawDesc), len(file_msg_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_msg_proto_goTypes,
+		DependencyIndexes: file_msg_proto_depIdxs,
+		EnumInfos:         file_msg_proto_enumTypes,
+		MessageInfos:      file_msg_proto_msgTypes,
+	}.Build()
+	File_msg_proto = out.File
+	file_msg_proto_goTypes = nil
+	file_msg_proto_depIdxs = nil
+}
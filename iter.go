@@ -0,0 +1,118 @@
+//go:build go1.23
+
+package doozer
+
+import (
+	"context"
+	"iter"
+)
+
+// Events returns an iterator over changes to files matching glob,
+// starting at rev. It yields each change in order, for as long as
+// the caller keeps ranging and ctx is not done. If an error occurs,
+// it is yielded with a zero Event and the iteration stops.
+//
+// For example:
+//
+//	for ev, err := range doozer.Events(ctx, c, "/config/**", rev) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Events(ctx context.Context, c Client, glob string, rev int64) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for {
+			ev, err := waitContext(ctx, c, glob, rev)
+			if err != nil {
+				yield(Event{}, err)
+				return
+			}
+			if !yield(ev, nil) {
+				return
+			}
+			rev = ev.Rev + 1
+		}
+	}
+}
+
+// Names returns an iterator over the names in dir, at revision rev,
+// in lexicographical order. Each name is requested only when the
+// caller is ready for it, so stopping early costs nothing more.
+func Names(ctx context.Context, c Client, dir string, rev int64) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		if c, ok := c.(*Conn); ok {
+			it := c.GetdirIter(ctx, dir, rev)
+			for it.Next() {
+				if !yield(it.Name(), nil) {
+					return
+				}
+			}
+			if err := it.Err(); err != nil {
+				yield("", err)
+			}
+			return
+		}
+
+		for off := 0; ; off++ {
+			if err := ctx.Err(); err != nil {
+				yield("", err)
+				return
+			}
+			names, err := c.Getdir(dir, rev, off, 1)
+			if isRange(err) || err == nil && len(names) == 0 {
+				return
+			}
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if !yield(names[0], nil) {
+				return
+			}
+		}
+	}
+}
+
+// Files returns an iterator over the files matching glob, at
+// revision rev, in lexicographical order. Like Names, it requests
+// each file only when the caller is ready for it.
+func Files(ctx context.Context, c Client, glob string, rev int64) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		if c, ok := c.(*Conn); ok {
+			it := c.WalkIter(ctx, glob, rev)
+			for it.Next() {
+				if !yield(it.Event(), nil) {
+					return
+				}
+			}
+			if err := it.Err(); err != nil {
+				yield(Event{}, err)
+			}
+			return
+		}
+
+		for off := 0; ; off++ {
+			if err := ctx.Err(); err != nil {
+				yield(Event{}, err)
+				return
+			}
+			evs, err := c.Walk(glob, rev, off, 1)
+			if isRange(err) || err == nil && len(evs) == 0 {
+				return
+			}
+			if err != nil {
+				yield(Event{}, err)
+				return
+			}
+			if !yield(evs[0], nil) {
+				return
+			}
+		}
+	}
+}
+
+func isRange(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Err == ErrRange
+}
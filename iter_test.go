@@ -0,0 +1,66 @@
+//go:build go1.23
+
+package doozer_test
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+)
+
+func TestIterClient(t *testing.T) {
+	srv := doozertest.NewServer()
+	c := srv.Client()
+	for _, p := range []string{"/d/b", "/d/a", "/d/c/x"} {
+		if _, err := c.Set(p, -1, []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rev := srv.Rev()
+	ctx := context.Background()
+
+	var names []string
+	for name, err := range doozer.Names(ctx, c, "/d", rev) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "c" {
+		t.Fatalf("Names gave %v, want [a b c]", names)
+	}
+
+	var paths []string
+	for ev, err := range doozer.Files(ctx, c, "/d/*", rev) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ev.Path)
+	}
+	if len(paths) != 2 || paths[0] != "/d/a" || paths[1] != "/d/b" {
+		t.Fatalf("Files gave %v, want [/d/a /d/b]", paths)
+	}
+
+	var revs []int64
+	for ev, err := range doozer.Events(ctx, c, "/d/**", 1) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		revs = append(revs, ev.Rev)
+		if len(revs) == 3 {
+			break
+		}
+	}
+	if revs[0] != 1 || revs[2] != 3 {
+		t.Fatalf("Events gave revs %v, want [1 2 3]", revs)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, err := range doozer.Events(cctx, c, "/d/**", rev+1) {
+		if err != context.Canceled {
+			t.Fatalf("Events after cancel: got %v, want Canceled", err)
+		}
+	}
+}
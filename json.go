@@ -0,0 +1,16 @@
+package doozer
+
+// GetJSON is GetValue with the JSON codec.
+func GetJSON(c Client, path string, rev *int64, v interface{}) (frev int64, err error) {
+	return GetValue(c, JSON, path, rev, v)
+}
+
+// SetJSON is SetValue with the JSON codec.
+func SetJSON(c Client, path string, oldRev int64, v interface{}) (newRev int64, err error) {
+	return SetValue(c, JSON, path, oldRev, v)
+}
+
+// UpdateJSON is UpdateValue with the JSON codec.
+func UpdateJSON(c Client, path string, v interface{}, fn func() error) (rev int64, err error) {
+	return UpdateValue(c, JSON, path, v, fn)
+}
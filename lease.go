@@ -0,0 +1,109 @@
+package doozer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Lease grants its holder a right, such as leadership, until an
+// expiry time the holder writes to the lease file. Unlike a Lock, a
+// Lease is renewed only when its holder calls Renew, and others read
+// its expiry from the file, so the clocks of the processes sharing a
+// Lease must roughly agree.
+//
+// Token is the revision at which the Lease was granted. Each holder's
+// token is greater than the one before it, so a resource that
+// records the greatest token it has seen can refuse requests from a
+// holder that has been deposed.
+type Lease struct {
+	Path    string
+	Token   int64
+	Expires time.Time
+
+	c   Client
+	id  string
+	rev int64 // revision of the file as last written
+}
+
+// Grant takes the lease at path for d, if it is free or has expired.
+// If another holds it, Grant fails with ErrLeaseHeld.
+func Grant(c Client, path string, d time.Duration) (*Lease, error) {
+	body, frev, err := c.Get(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if frev != missing {
+		if time.Now().Before(leaseExpiry(body)) {
+			return nil, ErrLeaseHeld
+		}
+	}
+
+	l := &Lease{Path: path, c: c, id: newID()}
+	l.Expires = time.Now().Add(d)
+	rev, err := c.Set(path, frev, l.body())
+	if e, ok := err.(*Error); ok && e.Err == ErrOldRev {
+		return nil, ErrLeaseHeld
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.Token, l.rev = rev, rev
+	return l, nil
+}
+
+// Renew extends the lease to d from now. It fails with ErrLeaseLost
+// if the lease has been taken by another since it was granted.
+func (l *Lease) Renew(d time.Duration) error {
+	exp := time.Now().Add(d)
+	old := l.Expires
+	l.Expires = exp
+	rev, err := l.c.Set(l.Path, l.rev, l.body())
+	if e, ok := err.(*Error); ok && e.Err == ErrOldRev {
+		l.Expires = old
+		return ErrLeaseLost
+	}
+	if err != nil {
+		l.Expires = old
+		return err
+	}
+	l.rev = rev
+	return nil
+}
+
+// Revoke gives up the lease, so another may be granted it at once.
+// It fails with ErrLeaseLost if the lease has been taken by another.
+func (l *Lease) Revoke() error {
+	err := l.c.Del(l.Path, l.rev)
+	if e, ok := err.(*Error); ok && e.Err == ErrOldRev {
+		return ErrLeaseLost
+	}
+	if err == nil {
+		l.Expires = time.Time{}
+	}
+	return err
+}
+
+// Valid reports whether the lease has yet to expire.
+func (l *Lease) Valid() bool {
+	return time.Now().Before(l.Expires)
+}
+
+// body returns the contents of the lease file: the holder's id and
+// the expiry time in nanoseconds since the Unix epoch.
+func (l *Lease) body() []byte {
+	return []byte(l.id + " " + strconv.FormatInt(l.Expires.UnixNano(), 10))
+}
+
+// leaseExpiry returns the expiry time written in a lease file.
+func leaseExpiry(body []byte) time.Time {
+	f := strings.Fields(string(body))
+	if len(f) != 2 {
+		return time.Time{}
+	}
+	n, err := strconv.ParseInt(f[1], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
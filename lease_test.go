@@ -0,0 +1,53 @@
+package doozer_test
+
+import (
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+	"time"
+)
+
+func TestLeaseExpiry(t *testing.T) {
+	const d = 100 * time.Millisecond
+	srv := doozertest.NewServer()
+	ca, cb := srv.Client(), srv.Client()
+
+	a, err := doozer.Grant(ca, "/lease", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doozer.Grant(cb, "/lease", d); err != doozer.ErrLeaseHeld {
+		t.Fatalf("Grant of a held lease: got %v, want ErrLeaseHeld", err)
+	}
+
+	// Renewed, the lease stays a's past its first expiry.
+	time.Sleep(d / 2)
+	if err := a.Renew(d); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(d / 2)
+	if _, err := doozer.Grant(cb, "/lease", d); err != doozer.ErrLeaseHeld {
+		t.Fatalf("Grant of a renewed lease: got %v, want ErrLeaseHeld", err)
+	}
+
+	time.Sleep(d)
+	if a.Valid() {
+		t.Fatal("lease valid after it expired")
+	}
+	b, err := doozer.Grant(cb, "/lease", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Token <= a.Token {
+		t.Fatalf("token %d is not after %d", b.Token, a.Token)
+	}
+	if err := a.Renew(d); err != doozer.ErrLeaseLost {
+		t.Fatalf("Renew of a lost lease: got %v, want ErrLeaseLost", err)
+	}
+	if err := a.Revoke(); err != doozer.ErrLeaseLost {
+		t.Fatalf("Revoke of a lost lease: got %v, want ErrLeaseLost", err)
+	}
+	if err := b.Revoke(); err != nil {
+		t.Fatal(err)
+	}
+}
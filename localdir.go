@@ -0,0 +1,114 @@
+package doozer
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ManifestName is the name of the file in which ExportDir records
+// the revision of each file it writes.
+const ManifestName = ".doozer-manifest"
+
+// ExportDir writes each file matching glob, as of revision rev, to
+// the same path beneath the local directory dir, creating
+// directories as needed. It also writes a manifest, dir/ManifestName,
+// with a line "<rev> <path>" for each file. It returns the number of
+// files written.
+func ExportDir(c Client, glob string, rev int64, dir string) (n int, err error) {
+	evs, err := walkAll(c, glob, rev)
+	if err != nil {
+		return 0, err
+	}
+
+	var manifest strings.Builder
+	for _, ev := range evs {
+		name := filepath.Join(dir, filepath.FromSlash(ev.Path))
+		err = os.MkdirAll(filepath.Dir(name), 0777)
+		if err != nil {
+			return n, err
+		}
+		err = ioutil.WriteFile(name, ev.Body, 0666)
+		if err != nil {
+			return n, err
+		}
+		fmt.Fprintln(&manifest, ev.Rev, ev.Path)
+		n++
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, ManifestName), []byte(manifest.String()), 0666)
+	return n, err
+}
+
+// ImportDir writes each regular file beneath the local directory dir
+// to the same path beneath prefix, such as to load a tree of
+// configuration checked out from version control. It returns the
+// number of files written.
+//
+// If dir holds a manifest written by ExportDir, each file listed in
+// it is written only if it has not changed in the store since it was
+// exported; ImportDir stops at the first that has, returning a
+// *PatchError. Other files are written regardless.
+func ImportDir(c Client, dir, prefix string) (n int, err error) {
+	revs, err := readManifest(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return 0, err
+	}
+
+	var changes []Change
+	err = filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil || rel == ManifestName {
+			return err
+		}
+		body, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		path := JoinPath(prefix, filepath.ToSlash(rel))
+		oldRev, ok := revs[path]
+		if !ok {
+			oldRev = clobber
+		}
+		changes = append(changes, Change{Path: path, OldRev: oldRev, Body: body})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return Apply(c, changes)
+}
+
+// readManifest returns the revisions recorded in the manifest at
+// name, or none if there is no manifest.
+func readManifest(name string) (map[string]int64, error) {
+	revs := make(map[string]int64)
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return revs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		i := strings.IndexByte(s.Text(), ' ')
+		if i < 0 {
+			return nil, &Error{ErrBadManifest, name}
+		}
+		rev, err := strconv.ParseInt(s.Text()[:i], 10, 64)
+		if err != nil {
+			return nil, &Error{ErrBadManifest, name}
+		}
+		revs[s.Text()[i+1:]] = rev
+	}
+	return revs, s.Err()
+}
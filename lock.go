@@ -0,0 +1,130 @@
+package doozer
+
+import (
+	"context"
+	"sync"
+)
+
+// A Lock is a mutex shared by every process using the store. It is
+// held by keeping a file at its path, written as an ephemeral file
+// of the holder's Session, so it lasts as long as the Session does.
+// A Lock whose holder's Session lapses is removed by another
+// Session's reaper, and may then be taken, so a holder whose Session
+// lapses, such as one cut off from the cluster, must stop relying on
+// it; Done tells it when.
+//
+// Acquiring the lock yields a fencing token, the revision at which
+// the lock was taken. Each holder's token is greater than the one
+// before it, so a resource that records the greatest token it has
+// seen can refuse requests from a holder that has been superseded.
+type Lock struct {
+	s    *Session
+	path string
+
+	mu    sync.Mutex
+	token int64 // revision of the file as written, or 0 if not held
+}
+
+// NewLock returns a Lock kept at path, held as part of s.
+func NewLock(s *Session, path string) *Lock {
+	return &Lock{s: s, path: path}
+}
+
+// TryAcquire takes the lock if it is free, returning the fencing
+// token. If another holds the lock, it fails with ErrLocked.
+func (l *Lock) TryAcquire() (token int64, err error) {
+	token, _, err = l.try()
+	return token, err
+}
+
+// Acquire takes the lock, waiting for it to be released, or removed
+// once its holder's Session lapses, if another holds it, and returns
+// the fencing token. It gives up when ctx is done.
+func (l *Lock) Acquire(ctx context.Context) (token int64, err error) {
+	for {
+		token, rev, err := l.try()
+		if err != ErrLocked || rev == missing {
+			return token, err
+		}
+
+		_, err = waitContext(ctx, l.s.c, l.path, rev+1)
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// try takes the lock if it is free. If not, it returns ErrLocked
+// along with the revision of the lock file, or missing if the Lock
+// itself holds it.
+func (l *Lock) try() (token, rev int64, err error) {
+	l.mu.Lock()
+	held := l.token != 0
+	l.mu.Unlock()
+	if held {
+		return 0, missing, ErrLocked
+	}
+
+	for {
+		token, err = l.s.create(l.path, []byte(l.s.ID))
+		if err == nil {
+			l.mu.Lock()
+			l.token = token
+			l.mu.Unlock()
+			return token, 0, nil
+		}
+		if e, ok := err.(*Error); !ok || e.Err != ErrOldRev {
+			return 0, 0, err
+		}
+
+		_, rev, err = l.s.c.Get(l.path, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+		if rev != missing {
+			return 0, rev, ErrLocked
+		}
+		// Released since; try again.
+	}
+}
+
+// Token returns the fencing token of the lock as held, or 0 if it is
+// not held.
+func (l *Lock) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
+
+// Done returns a channel that is closed if the lock, while held, is
+// lost, because its Session lapsed. It returns nil if the lock is
+// not held.
+func (l *Lock) Done() <-chan bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token == 0 {
+		return nil
+	}
+	return l.s.Done()
+}
+
+// Release deletes the lock file, so another may take the lock. It
+// fails with ErrLockLost if the lock was not held, or had been lost.
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	token := l.token
+	l.token = 0
+	l.mu.Unlock()
+	if token == 0 {
+		return ErrLockLost
+	}
+
+	err := l.s.remove(l.path, token)
+	if e, ok := err.(*Error); ok && (e.Err == ErrOldRev || e.Err == ErrNoEnt) || l.s.lapsed() {
+		return ErrLockLost
+	}
+	return err
+}
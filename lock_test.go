@@ -0,0 +1,103 @@
+package doozer_test
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+	"time"
+)
+
+const testTTL = 30 * time.Millisecond
+
+func openSession(t *testing.T, c doozer.Client) *doozer.Session {
+	s, err := doozer.OpenSession(c, "/sessions", testTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLockContention(t *testing.T) {
+	srv := doozertest.NewServer()
+	a := openSession(t, srv.Client())
+	defer a.Close()
+	b := openSession(t, srv.Client())
+	defer b.Close()
+
+	la := doozer.NewLock(a, "/lock")
+	lb := doozer.NewLock(b, "/lock")
+	first, err := la.TryAcquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lb.TryAcquire(); err != doozer.ErrLocked {
+		t.Fatalf("TryAcquire of a held lock: got %v, want ErrLocked", err)
+	}
+
+	got := make(chan int64, 1)
+	go func() {
+		token, err := lb.Acquire(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		got <- token
+	}()
+	select {
+	case <-got:
+		t.Fatal("Acquire returned while the lock was held")
+	case <-time.After(2 * testTTL):
+	}
+
+	if err := la.Release(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case token := <-got:
+		if token <= first {
+			t.Fatalf("token %d is not after %d", token, first)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return after Release")
+	}
+	if err := lb.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockExpiry(t *testing.T) {
+	srv := doozertest.NewServer()
+	ca := srv.Client()
+	a := openSession(t, ca)
+	defer a.Close()
+	b := openSession(t, srv.Client())
+	defer b.Close()
+
+	la := doozer.NewLock(a, "/lock")
+	first, err := la.TryAcquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a's process is cut off, and stops renewing its Session.
+	ca.Close()
+	select {
+	case <-la.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock not lost after its Session lapsed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lb := doozer.NewLock(b, "/lock")
+	token, err := lb.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token <= first {
+		t.Fatalf("token %d is not after %d", token, first)
+	}
+	if err := la.Release(); err != doozer.ErrLockLost {
+		t.Fatalf("Release of a lost lock: got %v, want ErrLockLost", err)
+	}
+}
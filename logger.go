@@ -0,0 +1,60 @@
+package doozer
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// A Logger receives warnings from a Conn, such as responses that
+// cannot be matched to a request. Each warning is a message followed
+// by alternating keys and values. A *slog.Logger is a Logger.
+type Logger interface {
+	Warn(msg string, keyvals ...interface{})
+}
+
+// StdLogger returns a Logger that writes to l, formatting each
+// key and value as key=value after the message. A nil l means
+// the standard logger.
+func StdLogger(l *log.Logger) Logger {
+	return stdLogger{l}
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s stdLogger) Warn(msg string, keyvals ...interface{}) {
+	a := []string{msg}
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 < len(keyvals) {
+			a = append(a, fmt.Sprintf("%v=%v", keyvals[i], keyvals[i+1]))
+		} else {
+			a = append(a, fmt.Sprint(keyvals[i]))
+		}
+	}
+	if s.l == nil {
+		log.Print(strings.Join(a, " "))
+	} else {
+		s.l.Print(strings.Join(a, " "))
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Warn(msg string, keyvals ...interface{}) {}
+
+func (cfg *Config) logger() Logger {
+	if cfg.Logger == nil {
+		return nopLogger{}
+	}
+	return cfg.Logger
+}
+
+// loggerOf returns the Logger configured for c, if it is a Conn.
+func loggerOf(c Client) Logger {
+	if c, ok := c.(*Conn); ok {
+		return c.cfg.logger()
+	}
+	return nopLogger{}
+}
@@ -0,0 +1,230 @@
+package doozer
+
+import (
+	"sort"
+	"sync"
+)
+
+// A MirrorFunc is called with each change applied to a Mirror.
+type MirrorFunc func(ev Event)
+
+// A Mirror holds a copy, in memory, of the files matching a glob and
+// keeps it up to date as they change, so reads need not go to the
+// server.
+type Mirror struct {
+	c    *Conn
+	glob string
+
+	mu     sync.Mutex
+	files  map[string]Event
+	rev    int64
+	funcs  []MirrorFunc
+	cancel CancelFunc
+	closed bool
+	err    error
+}
+
+// NewMirror reads the files matching glob at the current revision and
+// then follows them with WatchGaps. If the server discards history
+// the Mirror has yet to see, it reads the files again and reports
+// the difference as changes.
+func NewMirror(c *Conn, glob string) (*Mirror, error) {
+	rev, err := c.Rev()
+	if err != nil {
+		return nil, err
+	}
+
+	evs, err := c.Walk(glob, rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, cancel, err := c.WatchGaps(glob, rev+1)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mirror{
+		c:      c,
+		glob:   glob,
+		cancel: cancel,
+		files:  make(map[string]Event, len(evs)),
+		rev:    rev,
+	}
+	for _, ev := range evs {
+		m.files[ev.Path] = ev
+	}
+	go m.run(ch)
+	return m, nil
+}
+
+// Get returns the body and revision of the file at path. The last
+// result is false if no such file matches the glob.
+func (m *Mirror) Get(path string) (body []byte, rev int64, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ev, ok := m.files[path]
+	return ev.Body, ev.Rev, ok
+}
+
+// Paths returns the paths of the files, in order.
+func (m *Mirror) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	paths := make([]string, 0, len(m.files))
+	for path := range m.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Snapshot returns a copy of the bodies of the files, by path, and the
+// store revision they are current as of.
+func (m *Mirror) Snapshot() (files map[string][]byte, rev int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files = make(map[string][]byte, len(m.files))
+	for path, ev := range m.files {
+		files[path] = ev.Body
+	}
+	return files, m.rev
+}
+
+// Rev returns the store revision the copy is current as of.
+func (m *Mirror) Rev() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rev
+}
+
+// OnChange arranges for f to be called with each later change, after
+// it has been applied. Calls are made one at a time, in order, and a
+// slow f holds up the Mirror.
+func (m *Mirror) OnChange(f MirrorFunc) {
+	m.mu.Lock()
+	m.funcs = append(m.funcs, f)
+	m.mu.Unlock()
+}
+
+// Err returns the error that stopped the Mirror from following the
+// store, if any. The copy is no longer updated once it has.
+func (m *Mirror) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// Close stops updating the copy. It returns the error that had
+// already stopped it, if any.
+func (m *Mirror) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	cancel := m.cancel
+	m.mu.Unlock()
+
+	err := cancel()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := err.(*GapError); ok {
+		return nil
+	}
+	return err
+}
+
+func (m *Mirror) run(ch <-chan Event) {
+	for {
+		for ev := range ch {
+			m.apply([]Event{ev})
+		}
+
+		m.mu.Lock()
+		cancel := m.cancel
+		closed := m.closed
+		m.mu.Unlock()
+
+		err := cancel()
+		if gap, ok := err.(*GapError); ok && !closed {
+			ch, err = m.resync(gap.To)
+			if err == nil {
+				continue
+			}
+		}
+
+		m.mu.Lock()
+		if !m.closed {
+			m.err = err
+		}
+		m.mu.Unlock()
+		return
+	}
+}
+
+// apply makes the changes in evs to the copy and then calls the
+// change funcs with each of them.
+func (m *Mirror) apply(evs []Event) {
+	m.mu.Lock()
+	for _, ev := range evs {
+		if ev.IsDel() {
+			delete(m.files, ev.Path)
+		} else {
+			m.files[ev.Path] = ev
+		}
+		m.rev = ev.Rev
+	}
+	funcs := m.funcs
+	m.mu.Unlock()
+
+	for _, ev := range evs {
+		for _, f := range funcs {
+			f(ev)
+		}
+	}
+}
+
+// resync reads the files again as of rev, applies the differences
+// from the copy, and carries on watching from the next revision.
+func (m *Mirror) resync(rev int64) (<-chan Event, error) {
+	evs, err := m.c.Walk(m.glob, rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(evs))
+	var diff []Event
+	m.mu.Lock()
+	for _, ev := range evs {
+		seen[ev.Path] = true
+		if old, ok := m.files[ev.Path]; !ok || old.Rev != ev.Rev {
+			diff = append(diff, ev)
+		}
+	}
+	for path := range m.files {
+		if !seen[path] {
+			diff = append(diff, Event{Rev: rev, Path: path, Flag: del})
+		}
+	}
+	m.mu.Unlock()
+	sort.Stable(byRev(diff))
+
+	ch, cancel, err := m.c.WatchGaps(m.glob, rev+1)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.cancel = cancel
+	closed := m.closed
+	m.mu.Unlock()
+	if closed {
+		cancel()
+	}
+
+	m.apply(diff)
+	m.mu.Lock()
+	m.rev = rev
+	m.mu.Unlock()
+	return ch, nil
+}
@@ -0,0 +1,49 @@
+package doozer
+
+import (
+	"github.com/dcjones/doozer/internal/msg"
+)
+
+// The wire messages are generated into internal/msg from msg.proto.
+// They are known by lowercase names here, since Request and Response
+// name the interceptor's view of a call.
+type (
+	request      = msg.Request
+	response     = msg.Response
+	request_Verb = msg.Request_Verb
+	response_Err = msg.Response_Err
+)
+
+const (
+	request_GET    = msg.Request_GET
+	request_SET    = msg.Request_SET
+	request_DEL    = msg.Request_DEL
+	request_REV    = msg.Request_REV
+	request_WAIT   = msg.Request_WAIT
+	request_NOP    = msg.Request_NOP
+	request_WALK   = msg.Request_WALK
+	request_CANCEL = msg.Request_CANCEL
+	request_GETDIR = msg.Request_GETDIR
+	request_STAT   = msg.Request_STAT
+	request_ACCESS = msg.Request_ACCESS
+)
+
+const (
+	response_OTHER        = msg.Response_OTHER
+	response_TAG_IN_USE   = msg.Response_TAG_IN_USE
+	response_UNKNOWN_VERB = msg.Response_UNKNOWN_VERB
+	response_READONLY     = msg.Response_READONLY
+	response_TOO_LATE     = msg.Response_TOO_LATE
+	response_REV_MISMATCH = msg.Response_REV_MISMATCH
+	response_BAD_PATH     = msg.Response_BAD_PATH
+	response_MISSING_ARG  = msg.Response_MISSING_ARG
+	response_RANGE        = msg.Response_RANGE
+	response_NOTDIR       = msg.Response_NOTDIR
+	response_ISDIR        = msg.Response_ISDIR
+	response_NOENT        = msg.Response_NOENT
+)
+
+var (
+	request_Verb_name  = msg.Request_Verb_name
+	request_Verb_value = msg.Request_Verb_value
+)
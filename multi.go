@@ -0,0 +1,77 @@
+package doozer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A GetResult is the outcome of reading one file with GetMulti.
+type GetResult struct {
+	Body []byte
+	Rev  int64
+	Err  error
+}
+
+// GetMulti reads the files at paths, as Get does, sending up to c's
+// parallelism of requests at once. It returns the result for each
+// path in the same order as paths.
+func (c *Conn) GetMulti(paths []string, rev *int64) []GetResult {
+	rs := make([]GetResult, len(paths))
+	c.each(len(paths), func(i int) {
+		r := &rs[i]
+		r.Body, r.Rev, r.Err = c.Get(paths[i], rev)
+	})
+	return rs
+}
+
+// A FileWrite is one file to be written by SetMulti.
+type FileWrite struct {
+	Path   string
+	OldRev int64
+	Body   []byte
+}
+
+// A SetResult is the outcome of one write made by SetMulti.
+type SetResult struct {
+	Rev int64
+	Err error
+}
+
+// SetMulti makes the writes, as Set does, sending up to c's
+// parallelism of requests at once. It returns the result for each
+// write in the same order as writes. If strict is set, no write is
+// sent after one has failed; each of those fails with ErrSkipped.
+// Writes already sent when the failure is seen may still succeed.
+func (c *Conn) SetMulti(writes []FileWrite, strict bool) []SetResult {
+	rs := make([]SetResult, len(writes))
+	var failed int32
+	c.each(len(writes), func(i int) {
+		r, w := &rs[i], writes[i]
+		if strict && atomic.LoadInt32(&failed) != 0 {
+			r.Err = ErrSkipped
+			return
+		}
+		r.Rev, r.Err = c.Set(w.Path, w.OldRev, w.Body)
+		if r.Err != nil {
+			atomic.StoreInt32(&failed, 1)
+		}
+	})
+	return rs
+}
+
+// each calls f with each index below n, with up to c's parallelism
+// of calls in progress at once, and waits for them to return.
+func (c *Conn) each(n int, f func(i int)) {
+	sem := make(chan bool, c.cfg.parallelism())
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- true
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f(i)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+}
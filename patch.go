@@ -0,0 +1,115 @@
+package doozer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadPatch = errors.New("malformed patch")
+)
+
+// WritePatch writes changes to w in the textual patch format read
+// by ReadPatch. Each change is a record of the form
+//
+//	<path> <oldrev> <set|del> <len> LF <body> LF
+//
+// Here, <oldrev> is the revision the file must not have been
+// modified since (0 if it must not exist), <len> is the number of
+// bytes in the body, and LF is an ASCII line-feed char. The body of
+// a del record is empty.
+func WritePatch(w io.Writer, changes []Change) error {
+	bw := bufio.NewWriter(w)
+	for _, ch := range changes {
+		op := "set"
+		if ch.Del {
+			op = "del"
+		}
+		fmt.Fprintln(bw, ch.Path, ch.OldRev, op, len(ch.Body))
+		bw.Write(ch.Body)
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// ReadPatch reads changes written by WritePatch from r.
+func ReadPatch(r io.Reader) (changes []Change, err error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return changes, nil
+		}
+		if err != nil {
+			return nil, ErrBadPatch
+		}
+
+		f := strings.Fields(line)
+		if len(f) != 4 {
+			return nil, ErrBadPatch
+		}
+
+		var ch Change
+		ch.Path = f[0]
+		ch.OldRev, err = strconv.ParseInt(f[1], 10, 64)
+		if err != nil {
+			return nil, ErrBadPatch
+		}
+		switch f[2] {
+		case "set":
+		case "del":
+			ch.Del = true
+		default:
+			return nil, ErrBadPatch
+		}
+		n, err := strconv.Atoi(f[3])
+		if err != nil || n < 0 || ch.Del && n != 0 {
+			return nil, ErrBadPatch
+		}
+
+		ch.Body = make([]byte, n+1)
+		_, err = io.ReadFull(br, ch.Body)
+		if err != nil || ch.Body[n] != '\n' {
+			return nil, ErrBadPatch
+		}
+		ch.Body = ch.Body[:n]
+		if ch.Del {
+			ch.Body = nil
+		}
+
+		changes = append(changes, ch)
+	}
+}
+
+// Apply makes each change in turn, on the condition that the file
+// has not been modified since the change's OldRev. It stops at the
+// first change that cannot be made, returning the number of changes
+// made and the error.
+func Apply(c Client, changes []Change) (n int, err error) {
+	for _, ch := range changes {
+		if ch.Del {
+			err = c.Del(ch.Path, ch.OldRev)
+		} else {
+			_, err = c.Set(ch.Path, ch.OldRev, ch.Body)
+		}
+		if err != nil {
+			return n, &PatchError{ch.Path, err}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// A PatchError records the change that Apply could not make.
+type PatchError struct {
+	Path string
+	Err  error
+}
+
+func (e *PatchError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
@@ -0,0 +1,90 @@
+package doozer
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// CheckPath reports whether p is a well-formed path: "/" or a slash
+// followed by components separated by single slashes, each made of
+// letters, digits, '.', '-', and '_', and none "." or "..". If p is
+// not, it returns an *Error holding ErrBadPath.
+func CheckPath(p string) error {
+	return checkPath(p, false)
+}
+
+// CheckGlob is CheckPath for a glob, whose components may also
+// contain '*' and '?'.
+func CheckGlob(glob string) error {
+	return checkPath(glob, true)
+}
+
+func checkPath(p string, glob bool) error {
+	if p == "/" {
+		return nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return &Error{ErrBadPath, p}
+	}
+	for _, name := range strings.Split(p[1:], "/") {
+		if glob {
+			name = strings.NewReplacer("*", "x", "?", "x").Replace(name)
+		}
+		if !validName(name) {
+			return &Error{ErrBadPath, p}
+		}
+	}
+	return nil
+}
+
+// CleanPath returns the shortest path equivalent to p, as
+// path.Clean does, beginning with a slash. It removes repeated and
+// trailing slashes and resolves "." and ".." components; ".." at
+// the root stays at the root.
+func CleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// JoinPath joins elems into a single cleaned path. Components of
+// later elements cannot climb above the first, so JoinPath("/a",
+// "../b") is "/a/b"; each element is cleaned on its own first.
+func JoinPath(elems ...string) string {
+	if len(elems) == 0 {
+		return "/"
+	}
+	p := CleanPath(elems[0])
+	for _, e := range elems[1:] {
+		p = path.Join(p, CleanPath(e))
+	}
+	return p
+}
+
+// SplitPath returns the components of the cleaned p. The root has
+// none.
+func SplitPath(p string) []string {
+	p = CleanPath(p)
+	if p == "/" {
+		return nil
+	}
+	return strings.Split(p[1:], "/")
+}
+
+// CheckPaths returns an Interceptor that fails each request whose
+// path or glob is not well-formed, as reported by CheckPath or
+// CheckGlob, before sending it.
+func CheckPaths() Interceptor {
+	return func(ctx context.Context, req *Request, next Invoker) (*Response, error) {
+		var err error
+		switch req.Verb {
+		case "GET", "SET", "DEL", "STAT", "GETDIR":
+			err = CheckPath(req.Path)
+		case "WALK", "WAIT":
+			err = CheckGlob(req.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
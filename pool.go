@@ -0,0 +1,58 @@
+package doozer
+
+import (
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+// maxPooled is the largest buffer kept for reuse. Larger frames are
+// rare, and keeping their buffers would pin the memory indefinitely.
+const maxPooled = 64 << 10
+
+// marshalPool holds buffers for encoding requests.
+var marshalPool = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// readPool holds buffers for frames read from the server.
+var readPool = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// marshal encodes m into a buffer from the pool.
+// The buffer should be returned with putMarshal.
+func marshal(m proto.Message) (*[]byte, error) {
+	pb := marshalPool.Get().(*[]byte)
+	buf, err := proto.MarshalOptions{}.MarshalAppend((*pb)[:0], m)
+	if err != nil {
+		putMarshal(pb)
+		return nil, err
+	}
+	*pb = buf
+	return pb, nil
+}
+
+func putMarshal(pb *[]byte) {
+	if cap(*pb) > maxPooled {
+		return
+	}
+	*pb = (*pb)[:0]
+	marshalPool.Put(pb)
+}
+
+// getRead returns a buffer from the pool, grown to hold n bytes.
+func getRead(n int) *[]byte {
+	p := readPool.Get().(*[]byte)
+	if cap(*p) < n {
+		*p = make([]byte, n)
+	}
+	*p = (*p)[:n]
+	return p
+}
+
+func putRead(p *[]byte) {
+	if cap(*p) > maxPooled {
+		return
+	}
+	readPool.Put(p)
+}
@@ -0,0 +1,96 @@
+package doozer
+
+import (
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func getRequest() *request {
+	var r request
+	r.Tag = proto.Int32(1)
+	r.Verb = request_GET.Enum()
+	r.Path = proto.String("/ctl/node/a/addr")
+	r.Rev = proto.Int64(1234)
+	return &r
+}
+
+func waitRequest() *request {
+	var r request
+	r.Tag = proto.Int32(2)
+	r.Verb = request_WAIT.Enum()
+	r.Path = proto.String("/app/**")
+	r.Rev = proto.Int64(1235)
+	return &r
+}
+
+func getResponse() []byte {
+	buf, _ := proto.Marshal(&response{
+		Tag:   proto.Int32(1),
+		Rev:   proto.Int64(1234),
+		Value: make([]byte, 512),
+	})
+	return buf
+}
+
+func waitResponse() []byte {
+	buf, _ := proto.Marshal(&response{
+		Tag:   proto.Int32(2),
+		Rev:   proto.Int64(1235),
+		Path:  proto.String("/app/config"),
+		Value: make([]byte, 512),
+		Flags: proto.Int32(4),
+	})
+	return buf
+}
+
+func benchEncode(b *testing.B, r *request) {
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pb, err := marshal(r)
+			if err != nil {
+				b.Fatal(err)
+			}
+			putMarshal(pb)
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := proto.Marshal(r); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func benchDecode(b *testing.B, frame []byte) {
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p := getRead(len(frame))
+			copy(*p, frame)
+			var r response
+			if err := proto.Unmarshal(*p, &r); err != nil {
+				b.Fatal(err)
+			}
+			putRead(p)
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p := make([]byte, len(frame))
+			copy(p, frame)
+			var r response
+			if err := proto.Unmarshal(p, &r); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncodeGet(b *testing.B)  { benchEncode(b, getRequest()) }
+func BenchmarkEncodeWait(b *testing.B) { benchEncode(b, waitRequest()) }
+func BenchmarkDecodeGet(b *testing.B)  { benchDecode(b, getResponse()) }
+func BenchmarkDecodeWait(b *testing.B) { benchDecode(b, waitResponse()) }
@@ -0,0 +1,114 @@
+// Package prometheus exports the stats of a doozer connection
+// as Prometheus metrics.
+//
+// For example:
+//
+//	prom.MustRegister(prometheus.NewCollector(c, "config"))
+package prometheus
+
+import (
+	"github.com/dcjones/doozer"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"sort"
+)
+
+// A Collector collects the stats of a Conn each time it is scraped.
+type Collector struct {
+	c *doozer.Conn
+
+	requests   *prom.Desc
+	errors     *prom.Desc
+	latency    *prom.Desc
+	bytes      *prom.Desc
+	frames     *prom.Desc
+	inFlight   *prom.Desc
+	waits      *prom.Desc
+	reconnects *prom.Desc
+}
+
+// NewCollector returns a Collector for c. Its metrics carry the
+// label conn=name, to tell several connections apart.
+func NewCollector(c *doozer.Conn, name string) *Collector {
+	l := prom.Labels{"conn": name}
+	desc := func(metric, help string, labels ...string) *prom.Desc {
+		return prom.NewDesc("doozer_client_"+metric, help, labels, l)
+	}
+	return &Collector{
+		c:          c,
+		requests:   desc("requests_total", "Requests made, by verb.", "verb"),
+		errors:     desc("request_errors_total", "Requests that failed, by verb.", "verb"),
+		latency:    desc("request_duration_seconds", "Time taken by requests, by verb.", "verb"),
+		bytes:      desc("bytes_total", "Bytes moved, by direction.", "direction"),
+		frames:     desc("frame_size_bytes", "Size of frames, by direction.", "direction"),
+		inFlight:   desc("in_flight_requests", "Requests awaiting a result."),
+		waits:      desc("waits", "Waits awaiting an event."),
+		reconnects: desc("reconnects_total", "Connections replaced."),
+	}
+}
+
+func (col *Collector) Describe(ch chan<- *prom.Desc) {
+	ch <- col.requests
+	ch <- col.errors
+	ch <- col.latency
+	ch <- col.bytes
+	ch <- col.frames
+	ch <- col.inFlight
+	ch <- col.waits
+	ch <- col.reconnects
+}
+
+func (col *Collector) Collect(ch chan<- prom.Metric) {
+	st := col.c.Stats()
+
+	verbs := make([]string, 0, len(st.Verbs))
+	for verb := range st.Verbs {
+		verbs = append(verbs, verb)
+	}
+	sort.Strings(verbs)
+	for _, verb := range verbs {
+		vs := st.Verbs[verb]
+		ch <- prom.MustNewConstMetric(col.requests, prom.CounterValue, float64(vs.Requests), verb)
+		ch <- prom.MustNewConstMetric(col.errors, prom.CounterValue, float64(vs.Errors), verb)
+
+		h := vs.Latency
+		bounds := make([]float64, len(h.Bounds))
+		for i, b := range h.Bounds {
+			bounds[i] = b.Seconds()
+		}
+		n, buckets := cumulative(bounds, h.Counts)
+		ch <- prom.MustNewConstHistogram(col.latency, n, h.Sum.Seconds(), buckets, verb)
+	}
+
+	ch <- prom.MustNewConstMetric(col.bytes, prom.CounterValue, float64(st.BytesIn), "in")
+	ch <- prom.MustNewConstMetric(col.bytes, prom.CounterValue, float64(st.BytesOut), "out")
+	for _, f := range []struct {
+		dir string
+		h   doozer.SizeHistogram
+	}{{"in", st.FramesIn}, {"out", st.FramesOut}} {
+		bounds := make([]float64, len(f.h.Bounds))
+		for i, b := range f.h.Bounds {
+			bounds[i] = float64(b)
+		}
+		n, buckets := cumulative(bounds, f.h.Counts)
+		ch <- prom.MustNewConstHistogram(col.frames, n, float64(f.h.Sum), buckets, f.dir)
+	}
+
+	ch <- prom.MustNewConstMetric(col.inFlight, prom.GaugeValue, float64(st.InFlight))
+	ch <- prom.MustNewConstMetric(col.waits, prom.GaugeValue, float64(st.Waits))
+	ch <- prom.MustNewConstMetric(col.reconnects, prom.CounterValue, float64(st.Reconnects))
+}
+
+// cumulative converts per-bucket counts, as kept by doozer, to the
+// cumulative counts Prometheus expects, and returns their total.
+// The last count, of values above every bound, goes only in the total.
+func cumulative(bounds []float64, counts []int64) (uint64, map[float64]uint64) {
+	buckets := make(map[float64]uint64, len(bounds))
+	var n uint64
+	for i, c := range counts {
+		n += uint64(c)
+		if i < len(bounds) {
+			buckets[bounds[i]] = n
+		}
+	}
+	return n, buckets
+}
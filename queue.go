@@ -0,0 +1,119 @@
+package doozer
+
+import (
+	"context"
+	"sort"
+)
+
+// A Queue is a first-in, first-out queue of items shared by every
+// process using the store. Items are files in the Queue's items
+// directory, taken in the order they were put. A consumer claims
+// an item with a Lock in the claims directory while it works on it,
+// and deletes the item when done, so an item whose consumer dies
+// before then is claimed again once its Session has been reaped.
+type Queue struct {
+	s      *Session
+	c      Client
+	dir    string
+	items  string
+	claims string
+}
+
+// An Item is an entry taken from a Queue.
+type Item struct {
+	Name string
+	Body []byte
+	Rev  int64
+
+	c     Client
+	path  string
+	claim *Lock
+}
+
+// NewQueue returns a Queue kept under dir, whose items are claimed
+// as part of s.
+func NewQueue(s *Session, dir string) *Queue {
+	return &Queue{s: s, c: s.c, dir: dir, items: dir + "/items", claims: dir + "/claims"}
+}
+
+// Put adds an item with the given body to the end of the queue and
+// returns its name.
+func (q *Queue) Put(body []byte) (name string, err error) {
+	name = newID()
+	_, err = q.c.Set(q.items+"/"+name, missing, body)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Take claims the item at the front of the queue that no one else
+// has claimed, waiting for one to be put, released, or abandoned if
+// there is none. It gives up when ctx is done. The caller must call
+// Ack or Release on the item when done with it.
+func (q *Queue) Take(ctx context.Context) (*Item, error) {
+	for {
+		rev, err := q.c.Rev()
+		if err != nil {
+			return nil, err
+		}
+		items, err := GetTree(q.c, q.items, rev)
+		if err != nil {
+			return nil, err
+		}
+		claimed, err := GetTree(q.c, q.claims, rev)
+		if err != nil {
+			return nil, err
+		}
+		sort.Stable(byRev(items))
+
+		held := make(map[string]bool, len(claimed))
+		for _, ev := range claimed {
+			held[basename(ev.Path)] = true
+		}
+
+		for _, ev := range items {
+			name := basename(ev.Path)
+			if held[name] {
+				continue
+			}
+			claim := NewLock(q.s, q.claims+"/"+name)
+			_, err = claim.TryAcquire()
+			if err == nil {
+				return &Item{name, ev.Body, ev.Rev, q.c, ev.Path, claim}, nil
+			}
+			if err != ErrLocked {
+				return nil, err
+			}
+		}
+
+		_, err = waitContext(ctx, q.c, treeGlob(q.dir), rev+1)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Ack removes the item from the queue and gives up the claim on it.
+func (it *Item) Ack() error {
+	err := it.c.Del(it.path, it.Rev)
+	if err != nil {
+		return err
+	}
+	return it.claim.Release()
+}
+
+// Release gives up the claim on the item, leaving it in the queue
+// for another consumer to take.
+func (it *Item) Release() error {
+	return it.claim.Release()
+}
+
+// Done returns a channel that is closed if the claim on the item is
+// lost, as with Lock.Done, after which another consumer may take it.
+func (it *Item) Done() <-chan bool {
+	return it.claim.Done()
+}
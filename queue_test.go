@@ -0,0 +1,94 @@
+package doozer_test
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+	"time"
+)
+
+func TestQueueContention(t *testing.T) {
+	srv := doozertest.NewServer()
+	a := openSession(t, srv.Client())
+	defer a.Close()
+	b := openSession(t, srv.Client())
+	defer b.Close()
+	qa := doozer.NewQueue(a, "/q")
+	qb := doozer.NewQueue(b, "/q")
+
+	for _, body := range []string{"1", "2"} {
+		if _, err := qa.Put([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ia, err := qa.Take(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ib, err := qb.Take(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ia.Body) != "1" || string(ib.Body) != "2" {
+		t.Fatalf("took %q and %q, want 1 and 2", ia.Body, ib.Body)
+	}
+
+	// Released, an item is taken again; acked, it is gone.
+	if err := ib.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ia.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	it, err := qa.Take(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(it.Body) != "2" {
+		t.Fatalf("took %q, want 2", it.Body)
+	}
+	if err := it.Ack(); err != nil {
+		t.Fatal(err)
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := qb.Take(short); err != context.DeadlineExceeded {
+		t.Fatalf("Take of an empty queue: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestQueueAbandoned(t *testing.T) {
+	srv := doozertest.NewServer()
+	ca := srv.Client()
+	a := openSession(t, ca)
+	defer a.Close()
+	b := openSession(t, srv.Client())
+	defer b.Close()
+	qa := doozer.NewQueue(a, "/q")
+	qb := doozer.NewQueue(b, "/q")
+
+	if _, err := qa.Put([]byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := qa.Take(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// a's consumer dies holding the item; b takes it once a's
+	// Session is reaped.
+	ca.Close()
+	it, err := qb.Take(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(it.Body) != "1" {
+		t.Fatalf("took %q, want 1", it.Body)
+	}
+}
@@ -0,0 +1,97 @@
+package doozer
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// ServicesDir is the directory under which services are registered.
+const ServicesDir = "/services"
+
+// An Endpoint is an instance of a service, as registered with
+// Session.Register.
+type Endpoint struct {
+	Name string            `json:"-"` // entry in the service's directory
+	Addr string            `json:"addr"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Register adds an endpoint for the named service, at addr, to the
+// registry, as an ephemeral file of s, so that it is removed once s
+// ends or lapses. It returns the path of the entry, which may be
+// passed to s.Del to remove it sooner.
+func (s *Session) Register(service, addr string, meta map[string]string) (path string, err error) {
+	if !validName(service) {
+		return "", ErrBadName
+	}
+	body, err := json.Marshal(Endpoint{Addr: addr, Meta: meta})
+	if err != nil {
+		return "", err
+	}
+	path = ServicesDir + "/" + service + "/" + s.ID + "." + newID()
+	_, err = s.Set(path, body)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// A Discovery follows the endpoints registered for a service.
+type Discovery struct {
+	// C receives the endpoints after each change to them. If the
+	// receiver falls behind, only the latest list is kept.
+	C <-chan []Endpoint
+
+	m *Mirror
+}
+
+// Discover returns a Discovery of the endpoints of the named service.
+func Discover(c *Conn, service string) (*Discovery, error) {
+	if !validName(service) {
+		return nil, ErrBadName
+	}
+	m, err := NewMirror(c, ServicesDir+"/"+service+"/*")
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []Endpoint, 1)
+	d := &Discovery{C: ch, m: m}
+	m.OnChange(func(Event) {
+		eps := d.Endpoints()
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- eps
+	})
+	return d, nil
+}
+
+// Endpoints returns the endpoints registered, in order of name.
+// Entries that cannot be parsed are skipped.
+func (d *Discovery) Endpoints() []Endpoint {
+	files, _ := d.m.Snapshot()
+	eps := make([]Endpoint, 0, len(files))
+	for path, body := range files {
+		var ep Endpoint
+		if json.Unmarshal(body, &ep) != nil {
+			continue
+		}
+		ep.Name = basename(path)
+		eps = append(eps, ep)
+	}
+	sort.Sort(byName(eps))
+	return eps
+}
+
+// Close stops following the endpoints.
+func (d *Discovery) Close() error {
+	return d.m.Close()
+}
+
+type byName []Endpoint
+
+func (a byName) Len() int           { return len(a) }
+func (a byName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a byName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
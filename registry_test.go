@@ -0,0 +1,48 @@
+package doozer_test
+
+import (
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+)
+
+func TestRegisterExpiry(t *testing.T) {
+	srv := doozertest.NewServer()
+	ca := srv.Client()
+	a := openSession(t, ca)
+	defer a.Close()
+	b := openSession(t, srv.Client())
+	defer b.Close()
+
+	pa, err := a.Register("web", "10.0.0.1:80", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb, err := b.Register("web", "10.0.0.2:80", map[string]string{"zone": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pa == pb {
+		t.Fatalf("both endpoints registered at %s", pa)
+	}
+	c := srv.Client()
+	names, err := c.Getdir(doozer.ServicesDir+"/web", srv.Rev(), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("registered %v, want 2 endpoints", names)
+	}
+
+	// a's process dies; its endpoint goes once its Session is reaped,
+	// and b's stays.
+	ca.Close()
+	waitGone(t, c, pa)
+	if _, rev, _ := c.Get(pb, nil); rev == 0 {
+		t.Fatal("live endpoint removed")
+	}
+
+	if _, err := b.Register("a/b", "10.0.0.3:80", nil); err != doozer.ErrBadName {
+		t.Fatalf("Register of a bad name: got %v, want ErrBadName", err)
+	}
+}
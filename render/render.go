@@ -0,0 +1,228 @@
+// Package render keeps a file rendered from a template over files in
+// a doozer store, running a command to reload the program that reads
+// it each time it changes, in the manner of confd.
+//
+// For example:
+//
+//	t := template.Must(template.ParseFiles("haproxy.cfg.tmpl"))
+//	r := &render.Renderer{
+//		Template: t,
+//		Globs:    []string{"/services/web/*"},
+//		Dest:     "/etc/haproxy/haproxy.cfg",
+//		Reload:   []string{"systemctl", "reload", "haproxy"},
+//	}
+//	err := r.Run(ctx, c)
+package render
+
+import (
+	"bytes"
+	"context"
+	"github.com/dcjones/doozer"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// A Renderer renders Template to Dest, from the files matching Globs.
+type Renderer struct {
+	Template *template.Template
+	Globs    []string
+	Dest     string
+	Mode     os.FileMode // of Dest; zero means 0644
+
+	// Reload, if set, is a command, and its arguments, run after
+	// each change to Dest.
+	Reload []string
+
+	// Debounce is how long to wait after a change, for others that
+	// follow it, before rendering. Zero means 100ms.
+	Debounce time.Duration
+}
+
+// Data is the value a template is executed with.
+type Data struct {
+	Rev   int64             // revision of the store rendered
+	Files map[string][]byte // bodies of the files matching the globs
+}
+
+// Get returns the body of the file at path, or "" if none matched.
+func (d *Data) Get(path string) string {
+	return string(d.Files[path])
+}
+
+// Exists reports whether a file at path matched.
+func (d *Data) Exists(path string) bool {
+	_, ok := d.Files[path]
+	return ok
+}
+
+// Ls returns the names of the files and directories directly within
+// dir that hold files that matched, in order.
+func (d *Data) Ls(dir string) []string {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	seen := make(map[string]bool)
+	var names []string
+	for path := range d.Files {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		name := strings.SplitN(path[len(prefix):], "/", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Glob returns the paths of the files that matched and match pattern,
+// in order.
+func (d *Data) Glob(pattern string) ([]string, error) {
+	g, err := doozer.CompileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path := range d.Files {
+		if g.Match(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Run renders Dest, and renders it again after the files change,
+// until ctx is done or an error occurs, such as a failure of the
+// template or of Reload. Dest is written, and Reload run, only if
+// the output differs from what Dest holds.
+func (r *Renderer) Run(ctx context.Context, c *doozer.Conn) error {
+	changed := make(chan bool, 1)
+	var mirrors []*doozer.Mirror
+	defer func() {
+		for _, m := range mirrors {
+			m.Close()
+		}
+	}()
+	for _, glob := range r.Globs {
+		m, err := doozer.NewMirror(c, glob)
+		if err != nil {
+			return err
+		}
+		m.OnChange(func(doozer.Event) {
+			select {
+			case changed <- true:
+			default:
+			}
+		})
+		mirrors = append(mirrors, m)
+	}
+
+	debounce := r.Debounce
+	if debounce == 0 {
+		debounce = 100 * time.Millisecond
+	}
+	for {
+		d := &Data{Files: make(map[string][]byte)}
+		for _, m := range mirrors {
+			if err := m.Err(); err != nil {
+				return err
+			}
+			files, rev := m.Snapshot()
+			for path, body := range files {
+				d.Files[path] = body
+			}
+			if rev > d.Rev {
+				d.Rev = rev
+			}
+		}
+		if err := r.render(ctx, d); err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case <-time.After(debounce):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Render executes the template with the files matching the globs as
+// of revision rev, and returns the output.
+func (r *Renderer) Render(c doozer.Client, rev int64) ([]byte, error) {
+	d := &Data{Rev: rev, Files: make(map[string][]byte)}
+	for _, glob := range r.Globs {
+		evs, err := c.Walk(glob, rev, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		for _, ev := range evs {
+			d.Files[ev.Path] = ev.Body
+		}
+	}
+	return r.execute(d)
+}
+
+func (r *Renderer) execute(d *Data) ([]byte, error) {
+	var buf bytes.Buffer
+	err := r.Template.Execute(&buf, d)
+	return buf.Bytes(), err
+}
+
+// render writes the output for d to Dest, and runs Reload, if it
+// differs from what Dest holds.
+func (r *Renderer) render(ctx context.Context, d *Data) error {
+	out, err := r.execute(d)
+	if err != nil {
+		return err
+	}
+	old, err := ioutil.ReadFile(r.Dest)
+	if err == nil && bytes.Equal(old, out) {
+		return nil
+	}
+
+	mode := r.Mode
+	if mode == 0 {
+		mode = 0644
+	}
+	// Write to a temporary file and rename it into place, so that
+	// readers never see a partial file.
+	f, err := ioutil.TempFile(filepath.Dir(r.Dest), "."+filepath.Base(r.Dest))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(out)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), r.Dest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(r.Reload) == 0 {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, r.Reload[0], r.Reload[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,130 @@
+package doozer
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// A RetryPolicy says when a request that failed should be sent
+// again. Requests that only read the store (GET, STAT, GETDIR, WALK,
+// WAIT, REV, and NOP) are retried. Writes are retried only if
+// RetryWrites is set.
+//
+// When a request fails because the connection was lost, the Conn
+// reconnects before retrying it.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a request is sent,
+	// including the first. Less than 2 means never retry.
+	MaxAttempts int
+
+	// Backoff returns how long to wait before retry n, counting
+	// from 1. If nil, ExponentialBackoff(10ms, 1s) is used.
+	Backoff func(n int) time.Duration
+
+	// Retryable reports whether a request that failed with err
+	// should be retried. If nil, IsTransient is used.
+	Retryable func(err error) bool
+
+	// RetryWrites allows a Set or Del to be retried when it is
+	// conditioned on a revision, that is, when its rev is not -1.
+	// Such a write is never applied twice, but if an attempt
+	// succeeds and its response is lost, the retry fails with
+	// ErrOldRev.
+	RetryWrites bool
+}
+
+// IsTransient reports whether err is a failure of the connection,
+// rather than an error reported by the server, a cancellation, the
+// Conn being closed, or a refusal by the client itself, such as
+// ErrBusy, ErrNoTags, or ErrFrameSize.
+func IsTransient(err error) bool {
+	switch err {
+	case nil, context.Canceled, context.DeadlineExceeded, ErrClosed,
+		ErrBusy, ErrNoTags, ErrUnavailable, ErrFrameSize, ErrUnsupported:
+		return false
+	}
+	_, ok := err.(*Error)
+	return !ok
+}
+
+// ExponentialBackoff returns a Backoff function that doubles the
+// delay with each retry, starting at base and never exceeding max,
+// with up to 50% random jitter.
+func ExponentialBackoff(base, max time.Duration) func(n int) time.Duration {
+	return func(n int) time.Duration {
+		d := base
+		for i := 1; i < n && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+}
+
+func (p *RetryPolicy) allows(t *txn) bool {
+	switch *t.req.Verb {
+	case request_GET, request_STAT, request_GETDIR, request_WALK,
+		request_WAIT, request_REV, request_NOP:
+		return true
+	case request_SET, request_DEL:
+		return p.RetryWrites && t.req.Rev != nil && *t.req.Rev != clobber
+	}
+	return false
+}
+
+// retry sends t, and sends it again as c's RetryPolicy allows.
+func (c *Conn) retry(ctx context.Context, t *txn) error {
+	p := c.cfg.Retry
+	err := c.roundTrip(ctx, t)
+	if p == nil || !p.allows(t) {
+		return err
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(10*time.Millisecond, time.Second)
+	}
+
+	for n := 1; n < p.MaxAttempts && err != nil && retryable(err); n++ {
+		c.mu.Lock()
+		l, closed := c.l, c.closed
+		c.mu.Unlock()
+		if closed {
+			return err
+		}
+
+		timer := time.NewTimer(backoff(n))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		if rerr := c.repair(l); rerr != nil {
+			err = rerr
+			continue
+		}
+
+		t.reset()
+		err = c.roundTrip(ctx, t)
+	}
+	return err
+}
+
+// repair replaces l, c's link when last seen, if it has failed.
+func (c *Conn) repair(l *link) error {
+	select {
+	case <-l.stopped:
+		return c.relink(l)
+	default:
+	}
+	return nil
+}
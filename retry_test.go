@@ -0,0 +1,23 @@
+package doozer
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	for _, err := range []error{io.EOF, errReplaced} {
+		if !IsTransient(err) {
+			t.Errorf("IsTransient(%v) = false, want true", err)
+		}
+	}
+	for _, err := range []error{
+		nil, context.Canceled, ErrClosed, ErrBusy, ErrNoTags,
+		ErrFrameSize, &Error{ErrUnsupported, "WALK"}, &Error{ErrNoEnt, ""},
+	} {
+		if IsTransient(err) {
+			t.Errorf("IsTransient(%v) = true, want false", err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package doozer
+
+import (
+	"encoding/binary"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// A Script stands in for a doozer server, answering each request
+// from a table of canned replies. It lets tests exercise how code
+// using a Conn handles particular responses, such as ErrTooLate
+// from Wait or a run of ErrOldRev from Set, without a real server.
+type Script struct {
+	mu      sync.Mutex
+	replies map[string][]Reply
+	delays  map[string]time.Duration
+	fails   map[string]error
+}
+
+// A Reply is a canned response to a request.
+//
+// If Err is one of the server errors, such as ErrNoEnt, or an *Error
+// wrapping one, the reply carries that error. Any other non-nil Err
+// makes the Script drop the connection instead of replying.
+type Reply struct {
+	Rev   int64
+	Path  string
+	Body  []byte
+	Len   int
+	Del   bool // for WAIT and WALK, whether the event is a deletion
+	Err   error
+	Delay time.Duration
+}
+
+// NewScript returns a Script with no replies.
+// Requests it has no reply for fail with ErrOther.
+func NewScript() *Script {
+	return &Script{
+		replies: make(map[string][]Reply),
+		delays:  make(map[string]time.Duration),
+		fails:   make(map[string]error),
+	}
+}
+
+// On queues replies for requests with the given verb, such as "GET"
+// or "WAIT", and path. Each request takes the next reply in the
+// queue; the last one is repeated once the rest are used up. An
+// empty path matches requests for any path without a queue of their
+// own.
+func (s *Script) On(verb, path string, replies ...Reply) {
+	mustVerb(verb)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := verb + " " + path
+	s.replies[k] = append(s.replies[k], replies...)
+}
+
+// Delay holds every reply to requests with the given verb for d.
+func (s *Script) Delay(verb string, d time.Duration) {
+	mustVerb(verb)
+	s.mu.Lock()
+	s.delays[verb] = d
+	s.mu.Unlock()
+}
+
+// Fail answers every request with the given verb with err, as if
+// it were the Err of a Reply, regardless of any queued replies.
+// A nil err undoes a previous Fail.
+func (s *Script) Fail(verb string, err error) {
+	mustVerb(verb)
+	s.mu.Lock()
+	if err == nil {
+		delete(s.fails, verb)
+	} else {
+		s.fails[verb] = err
+	}
+	s.mu.Unlock()
+}
+
+// Conn returns a new Conn whose requests are answered by s.
+func (s *Script) Conn() *Conn {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return NewConn(client, nil)
+}
+
+func mustVerb(verb string) {
+	if _, ok := request_Verb_value[verb]; !ok {
+		panic("doozer: unknown verb " + verb)
+	}
+}
+
+func (s *Script) next(verb, path string) (r Reply, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delay = s.delays[verb]
+	if err, ok := s.fails[verb]; ok {
+		return Reply{Err: err}, delay
+	}
+
+	k := verb + " " + path
+	q, ok := s.replies[k]
+	if !ok {
+		k = verb + " "
+		q, ok = s.replies[k]
+	}
+	if !ok || len(q) == 0 {
+		return Reply{Err: &Error{ErrOther, "no reply scripted for " + verb + " " + path}}, delay
+	}
+	if len(q) > 1 {
+		s.replies[k] = q[1:]
+	}
+	return q[0], delay
+}
+
+func (s *Script) serve(nc net.Conn) {
+	defer nc.Close()
+
+	var wmu sync.Mutex
+	for {
+		var size int32
+		err := binary.Read(nc, binary.BigEndian, &size)
+		if err != nil {
+			return
+		}
+		buf := make([]byte, size)
+		_, err = io.ReadFull(nc, buf)
+		if err != nil {
+			return
+		}
+
+		var req request
+		err = proto.Unmarshal(buf, &req)
+		if err != nil || req.Verb == nil {
+			return
+		}
+
+		verb := request_Verb_name[int32(*req.Verb)]
+		r, delay := s.next(verb, req.GetPath())
+		go func(tag *int32) {
+			time.Sleep(delay + r.Delay)
+
+			resp, ok := r.response(*req.Verb)
+			if !ok {
+				nc.Close()
+				return
+			}
+			resp.Tag = tag
+			buf, err := proto.Marshal(resp)
+			if err != nil {
+				nc.Close()
+				return
+			}
+
+			wmu.Lock()
+			defer wmu.Unlock()
+			binary.Write(nc, binary.BigEndian, int32(len(buf)))
+			nc.Write(buf)
+		}(req.Tag)
+	}
+}
+
+// response converts r to a response to a request with verb v.
+// It returns false if r calls for dropping the connection instead.
+func (r Reply) response(v request_Verb) (*response, bool) {
+	var resp response
+
+	switch err := r.Err.(type) {
+	case nil:
+	case response_Err:
+		resp.ErrCode = err.Enum()
+		return &resp, true
+	case *Error:
+		code, ok := err.Err.(response_Err)
+		if !ok {
+			return nil, false
+		}
+		resp.ErrCode = code.Enum()
+		if err.Detail != "" {
+			resp.ErrDetail = proto.String(err.Detail)
+		}
+		return &resp, true
+	default:
+		return nil, false
+	}
+
+	resp.Rev = proto.Int64(r.Rev)
+	resp.Path = proto.String(r.Path)
+	resp.Value = r.Body
+	resp.Len = proto.Int32(int32(r.Len))
+	if v == request_WAIT || v == request_WALK {
+		flag := int32(set)
+		if r.Del {
+			flag = del
+		}
+		resp.Flags = proto.Int32(flag)
+	}
+	return &resp, true
+}
@@ -0,0 +1,144 @@
+package doozer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// A Semaphore lets up to n processes using the store hold it at
+// once. Each holder holds one of n slots, numbered from 0, each a
+// Lock at a file under the Semaphore's path, so a slot whose holder's
+// Session lapses is freed once the Session is reaped, as with Lock.
+type Semaphore struct {
+	s    *Session
+	path string
+	n    int
+
+	mu   sync.Mutex
+	held *Lock
+	busy bool // whether an acquisition is in progress
+}
+
+// NewSemaphore returns a Semaphore of n slots kept under path, held
+// as part of s.
+func NewSemaphore(s *Session, path string, n int) *Semaphore {
+	return &Semaphore{s: s, path: path, n: n}
+}
+
+// slots returns a Lock for each slot.
+func (s *Semaphore) slots() []*Lock {
+	locks := make([]*Lock, s.n)
+	for i := range locks {
+		locks[i] = NewLock(s.s, s.path+"/"+strconv.Itoa(i))
+	}
+	return locks
+}
+
+// TryAcquire takes a free slot, if there is one, returning its
+// number and fencing token. If every slot is held, it fails with
+// ErrLocked.
+func (s *Semaphore) TryAcquire() (slot int, token int64, err error) {
+	if !s.begin() {
+		return 0, 0, ErrLocked
+	}
+	defer s.end()
+
+	slot, token, _, err = s.try(s.slots())
+	return slot, token, err
+}
+
+// Acquire takes a slot, waiting for one to be released, or freed by
+// its holder's Session lapsing, if every slot is held, and returns
+// its number and fencing token. It gives up when ctx is done.
+func (s *Semaphore) Acquire(ctx context.Context) (slot int, token int64, err error) {
+	if !s.begin() {
+		return 0, 0, ErrLocked
+	}
+	defer s.end()
+
+	locks := s.slots()
+	if len(locks) == 0 {
+		return 0, 0, ErrLocked
+	}
+	for {
+		slot, token, last, err := s.try(locks)
+		if err != ErrLocked {
+			return slot, token, err
+		}
+
+		_, err = waitContext(ctx, s.s.c, s.path+"/*", last+1)
+		if ctx.Err() != nil {
+			return 0, 0, ctx.Err()
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+// try takes the first free slot among locks. If every slot is held,
+// it returns ErrLocked along with the latest revision of their files.
+func (s *Semaphore) try(locks []*Lock) (slot int, token, last int64, err error) {
+	for i, l := range locks {
+		token, rev, err := l.try()
+		if err == nil {
+			s.hold(l)
+			return i, token, 0, nil
+		}
+		if err != ErrLocked {
+			return 0, 0, 0, err
+		}
+		if rev > last {
+			last = rev
+		}
+	}
+	return 0, 0, last, ErrLocked
+}
+
+// begin reports whether s may acquire a slot, and if so, marks it as
+// doing so until end is called.
+func (s *Semaphore) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held != nil || s.busy {
+		return false
+	}
+	s.busy = true
+	return true
+}
+
+func (s *Semaphore) end() {
+	s.mu.Lock()
+	s.busy = false
+	s.mu.Unlock()
+}
+
+func (s *Semaphore) hold(l *Lock) {
+	s.mu.Lock()
+	s.held = l
+	s.mu.Unlock()
+}
+
+// Done returns a channel that is closed if the slot held is lost, as
+// with Lock.Done. It returns nil if no slot is held.
+func (s *Semaphore) Done() <-chan bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held == nil {
+		return nil
+	}
+	return s.held.Done()
+}
+
+// Release frees the slot held, as Lock.Release does.
+func (s *Semaphore) Release() error {
+	s.mu.Lock()
+	l := s.held
+	s.held = nil
+	s.mu.Unlock()
+	if l == nil {
+		return ErrLockLost
+	}
+	return l.Release()
+}
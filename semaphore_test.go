@@ -0,0 +1,60 @@
+package doozer_test
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreContention(t *testing.T) {
+	srv := doozertest.NewServer()
+	var sems []*doozer.Semaphore
+	for i := 0; i < 3; i++ {
+		s := openSession(t, srv.Client())
+		defer s.Close()
+		sems = append(sems, doozer.NewSemaphore(s, "/sem", 2))
+	}
+
+	seen := make(map[int]bool)
+	for _, sem := range sems[:2] {
+		slot, _, err := sem.TryAcquire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[slot] {
+			t.Fatalf("slot %d held twice", slot)
+		}
+		seen[slot] = true
+	}
+	if _, _, err := sems[2].TryAcquire(); err != doozer.ErrLocked {
+		t.Fatalf("TryAcquire with every slot held: got %v, want ErrLocked", err)
+	}
+
+	got := make(chan int, 1)
+	go func() {
+		slot, _, err := sems[2].Acquire(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		got <- slot
+	}()
+	select {
+	case <-got:
+		t.Fatal("Acquire returned with every slot held")
+	case <-time.After(2 * testTTL):
+	}
+
+	if err := sems[1].Release(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return after Release")
+	}
+	if _, _, err := sems[1].TryAcquire(); err != doozer.ErrLocked {
+		t.Fatalf("TryAcquire with every slot held: got %v, want ErrLocked", err)
+	}
+}
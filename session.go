@@ -0,0 +1,341 @@
+package doozer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSessionTTL is the TTL used when OpenSession is given none.
+const DefaultSessionTTL = 10 * time.Second
+
+// A Session marks a process as alive, so that files it writes as
+// ephemeral, and the Locks it holds, are removed once it is not.
+//
+// Each Session keeps a directory of its own under a common sessions
+// directory, holding an alive file, which the Session rewrites every
+// third of its TTL, and an entry recording each ephemeral file. A
+// Session that cannot rewrite its alive file for a whole TTL, or
+// finds it changed by another, has lapsed. Every Session sharing the
+// sessions directory watches for others whose alive file has gone
+// unmodified for their TTL, and removes their ephemeral files and
+// directory. A file lingers, then, only while some Session is open.
+type Session struct {
+	// ID names the Session's directory.
+	ID string
+
+	c     Client
+	dir   string
+	own   string
+	alive string
+	ttl   time.Duration
+	stop  chan bool
+	done  chan bool // closed when s lapses
+	wg    sync.WaitGroup
+
+	mu    sync.Mutex
+	rev   int64             // revision of the alive file as last written
+	files map[string]string // entry name for each ephemeral path
+	n     int
+}
+
+// OpenSession starts a Session in the sessions directory dir. A ttl
+// of zero means DefaultSessionTTL.
+func OpenSession(c Client, dir string, ttl time.Duration) (*Session, error) {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	id := newID()
+	s := &Session{
+		ID:    id,
+		c:     c,
+		dir:   dir,
+		own:   dir + "/" + id,
+		alive: dir + "/" + id + "/alive",
+		ttl:   ttl,
+		stop:  make(chan bool),
+		done:  make(chan bool),
+		files: make(map[string]string),
+	}
+	rev, err := c.Set(s.alive, missing, s.aliveBody())
+	if err != nil {
+		return nil, err
+	}
+	s.rev = rev
+
+	s.wg.Add(2)
+	go s.renew()
+	go s.reap()
+	return s, nil
+}
+
+// newID returns a random identifier for a participant in a protocol
+// such as Session.
+func newID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// aliveBody returns the contents of the alive file: the Session's id
+// and its TTL in nanoseconds.
+func (s *Session) aliveBody() []byte {
+	return []byte(s.ID + " " + strconv.FormatInt(int64(s.ttl), 10))
+}
+
+func parseTTL(body []byte, def time.Duration) time.Duration {
+	f := strings.Fields(string(body))
+	if len(f) == 2 {
+		if n, err := strconv.ParseInt(f[1], 10, 64); err == nil && n > 0 {
+			return time.Duration(n)
+		}
+	}
+	return def
+}
+
+// renew rewrites the alive file every third of the TTL until s is
+// closed. If it cannot do so for a whole TTL, or the file has been
+// changed by another, s has lapsed, and it closes s.done.
+func (s *Session) renew() {
+	defer s.wg.Done()
+	tick := time.NewTicker(s.ttl / 3)
+	defer tick.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick.C:
+		}
+
+		s.mu.Lock()
+		rev := s.rev
+		s.mu.Unlock()
+
+		frev, err := s.c.Set(s.alive, rev, s.aliveBody())
+		if err == nil {
+			s.mu.Lock()
+			s.rev = frev
+			s.mu.Unlock()
+			last = time.Now()
+			continue
+		}
+		if _, ok := err.(*Error); ok || time.Since(last) >= s.ttl {
+			close(s.done)
+			return
+		}
+	}
+}
+
+// lapsed reports whether s has lapsed.
+func (s *Session) lapsed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Set writes body to the file at path, as an ephemeral file of s.
+func (s *Session) Set(path string, body []byte) (rev int64, err error) {
+	return s.write(path, clobber, body)
+}
+
+// create writes body to the file at path, as an ephemeral file of s,
+// if there is no file there already. The file's body should name s,
+// so that if s lapses before recording the file's revision, its
+// reaper can tell the file is s's to remove.
+func (s *Session) create(path string, body []byte) (rev int64, err error) {
+	return s.write(path, missing, body)
+}
+
+func (s *Session) write(path string, oldRev int64, body []byte) (rev int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lapsed() {
+		return 0, ErrSessionLost
+	}
+	name, ok := s.files[path]
+	if !ok {
+		name = strconv.Itoa(s.n)
+		s.n++
+	}
+	// Record the file before writing it, so it is removed even if
+	// the process dies before it can record the new revision. A file
+	// created only if missing might be another's, so it is removed
+	// then only if it names s.
+	entry := s.own + "/files/" + name
+	mark := "0 "
+	if oldRev == missing {
+		mark = "- "
+	}
+	_, err = s.c.Set(entry, clobber, []byte(mark+path))
+	if err != nil {
+		return 0, err
+	}
+
+	rev, err = s.c.Set(path, oldRev, body)
+	if err != nil {
+		if !ok {
+			s.c.Del(entry, clobber)
+		}
+		return 0, err
+	}
+	s.files[path] = name
+	_, err = s.c.Set(entry, clobber, []byte(strconv.FormatInt(rev, 10)+" "+path))
+	return rev, err
+}
+
+// Del deletes the ephemeral file at path.
+func (s *Session) Del(path string) error {
+	return s.remove(path, clobber)
+}
+
+// remove deletes the ephemeral file at path, if it is still at rev.
+func (s *Session) remove(path string, rev int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, ok := s.files[path]
+	if !ok {
+		return &Error{ErrNoEnt, path}
+	}
+	err := s.c.Del(path, rev)
+	if err != nil {
+		return err
+	}
+	delete(s.files, path)
+	return s.c.Del(s.own+"/files/"+name, clobber)
+}
+
+// Done returns a channel that is closed if s lapses, because its
+// alive file could not be renewed in time. Its ephemeral files may
+// then be removed at any moment.
+func (s *Session) Done() <-chan bool {
+	return s.done
+}
+
+// Close removes the ephemeral files of s and ends it. It fails with
+// ErrSessionLost if s had lapsed.
+func (s *Session) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lapsed() {
+		return ErrSessionLost
+	}
+	for path := range s.files {
+		s.c.Del(path, clobber)
+	}
+	s.files = nil
+
+	rev, err := s.c.Rev()
+	if err == nil {
+		_, err = DelTree(s.c, s.own+"/files", rev)
+	}
+	derr := s.c.Del(s.alive, s.rev)
+	if e, ok := derr.(*Error); ok && e.Err == ErrOldRev {
+		derr = ErrSessionLost
+	}
+	if err == nil {
+		err = derr
+	}
+	return err
+}
+
+// reap removes the ephemeral files of lapsed sessions, checking every
+// TTL until s is closed.
+func (s *Session) reap() {
+	defer s.wg.Done()
+
+	type seen struct {
+		rev      int64
+		deadline time.Time
+	}
+	alive := make(map[string]seen)
+	tick := time.NewTicker(s.ttl)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick.C:
+		}
+
+		rev, err := s.c.Rev()
+		if err != nil {
+			continue
+		}
+		ids, err := s.c.Getdir(s.dir, rev, 0, -1)
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			if id == s.ID {
+				continue
+			}
+			body, frev, err := s.c.Get(s.dir+"/"+id+"/alive", &rev)
+			if err != nil {
+				continue
+			}
+			if a, ok := alive[id]; !ok || a.rev != frev {
+				alive[id] = seen{frev, time.Now().Add(parseTTL(body, s.ttl))}
+				continue
+			}
+			if time.Now().Before(alive[id].deadline) {
+				continue
+			}
+			delete(alive, id)
+			s.reapOne(id, frev)
+		}
+	}
+}
+
+// reapOne removes the ephemeral files and directory of the lapsed
+// session id, whose alive file was last written at rev.
+func (s *Session) reapOne(id string, rev int64) {
+	dir := s.dir + "/" + id
+
+	// Claim the alive file, so that no other Session reaps id too,
+	// and id, if it is not dead after all, learns it has lapsed.
+	rev, err := s.c.Set(dir+"/alive", rev, []byte(s.ID+" reaping"))
+	if err != nil {
+		return
+	}
+
+	cur, err := s.c.Rev()
+	if err == nil {
+		evs, err := GetTree(s.c, dir+"/files", cur)
+		if err == nil {
+			for _, ev := range evs {
+				f := strings.SplitN(string(ev.Body), " ", 2)
+				if len(f) != 2 {
+					continue
+				}
+				if f[0] == "-" {
+					// Created only if missing; remove it if it is id's.
+					body, frev, err := s.c.Get(f[1], &cur)
+					if err == nil && frev != missing && string(body) == id {
+						s.c.Del(f[1], frev)
+					}
+					s.c.Del(ev.Path, ev.Rev)
+					continue
+				}
+				frev, err := strconv.ParseInt(f[0], 10, 64)
+				if err != nil || frev == 0 {
+					frev = clobber
+				}
+				s.c.Del(f[1], frev)
+				s.c.Del(ev.Path, ev.Rev)
+			}
+		}
+	}
+	s.c.Del(dir+"/alive", rev)
+}
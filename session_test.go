@@ -0,0 +1,76 @@
+package doozer_test
+
+import (
+	"github.com/dcjones/doozer"
+	"github.com/dcjones/doozer/doozertest"
+	"testing"
+	"time"
+)
+
+// waitGone waits until there is no file at path.
+func waitGone(t *testing.T, c doozer.Client, path string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, rev, err := c.Get(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rev == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s not removed", path)
+		}
+		time.Sleep(testTTL / 3)
+	}
+}
+
+func TestSessionClose(t *testing.T) {
+	srv := doozertest.NewServer()
+	c := srv.Client()
+	s := openSession(t, c)
+	if _, err := s.Set("/eph", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renewed, the Session keeps its files for many TTLs.
+	time.Sleep(4 * testTTL)
+	if _, rev, _ := c.Get("/eph", nil); rev == 0 {
+		t.Fatal("ephemeral file removed while its Session was open")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitGone(t, c, "/eph")
+	if names, err := c.Getdir("/sessions", srv.Rev(), 0, -1); err == nil {
+		t.Fatalf("sessions left after Close: %v", names)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	srv := doozertest.NewServer()
+	ca := srv.Client()
+	a := openSession(t, ca)
+	defer a.Close()
+	b := openSession(t, srv.Client())
+	defer b.Close()
+
+	if _, err := a.Set("/eph", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	ca.Close()
+	select {
+	case <-a.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Session did not lapse")
+	}
+	if _, err := a.Set("/eph2", nil); err != doozer.ErrSessionLost {
+		t.Fatalf("Set on a lapsed Session: got %v, want ErrSessionLost", err)
+	}
+
+	c := srv.Client()
+	waitGone(t, c, "/eph")
+	waitGone(t, c, "/sessions/"+a.ID+"/alive")
+}
@@ -0,0 +1,148 @@
+package doozer
+
+import (
+	"sync"
+	"time"
+)
+
+// A Shadow is a Client that writes to a secondary store, in the
+// background, each change made through it to its primary, such as
+// to keep a new cluster in step with an old one during a migration.
+// Reads go to the primary alone.
+//
+// Changes are sent to the secondary in the order they were made,
+// unconditionally, since revisions differ between the stores. A
+// change the secondary fails is reported to OnError and dropped.
+type Shadow struct {
+	Client // the primary
+
+	// OnError, if set, is called with each change the secondary
+	// fails, from the goroutine that sends them.
+	OnError func(ch Change, err error)
+
+	secondary Client
+
+	mu      sync.Mutex
+	cond    sync.Cond
+	queue   []shadowWrite
+	sending *shadowWrite
+	sent    int64
+	failed  int64
+	closed  bool
+	done    chan bool
+}
+
+type shadowWrite struct {
+	ch Change
+	at time.Time
+}
+
+// ShadowStats describes the changes a Shadow has sent.
+type ShadowStats struct {
+	Pending int           // changes yet to be sent
+	Lag     time.Duration // age of the oldest pending change
+	Sent    int64
+	Failed  int64
+}
+
+// NewShadow returns a Shadow writing to both primary and secondary.
+func NewShadow(primary, secondary Client) *Shadow {
+	s := &Shadow{Client: primary, secondary: secondary, done: make(chan bool)}
+	s.cond.L = &s.mu
+	go s.run()
+	return s
+}
+
+// Set writes to the primary, as its Set does, and, if that succeeds,
+// queues the write for the secondary.
+func (s *Shadow) Set(file string, oldRev int64, body []byte) (newRev int64, err error) {
+	newRev, err = s.Client.Set(file, oldRev, body)
+	if err == nil {
+		s.push(Change{Path: file, OldRev: clobber, Body: body})
+	}
+	return newRev, err
+}
+
+// Del deletes from the primary, as its Del does, and, if that
+// succeeds, queues the deletion for the secondary.
+func (s *Shadow) Del(file string, rev int64) error {
+	err := s.Client.Del(file, rev)
+	if err == nil {
+		s.push(Change{Path: file, OldRev: clobber, Del: true})
+	}
+	return err
+}
+
+// Stats returns a snapshot of the changes sent so far.
+func (s *Shadow) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := ShadowStats{Pending: len(s.queue), Sent: s.sent, Failed: s.failed}
+	oldest := s.sending
+	if oldest == nil && len(s.queue) > 0 {
+		oldest = &s.queue[0]
+	}
+	if oldest != nil {
+		st.Pending++
+		st.Lag = time.Since(oldest.at)
+	}
+	return st
+}
+
+// Close sends the changes still pending, and then closes both the
+// primary and the secondary.
+func (s *Shadow) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Signal()
+	s.mu.Unlock()
+	<-s.done
+	s.Client.Close()
+	s.secondary.Close()
+}
+
+func (s *Shadow) push(ch Change) {
+	s.mu.Lock()
+	s.queue = append(s.queue, shadowWrite{ch, time.Now()})
+	s.cond.Signal()
+	s.mu.Unlock()
+}
+
+func (s *Shadow) run() {
+	defer close(s.done)
+	s.mu.Lock()
+	for {
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		w := s.queue[0]
+		s.queue = s.queue[1:]
+		s.sending = &w
+		s.mu.Unlock()
+
+		var err error
+		if w.ch.Del {
+			err = s.secondary.Del(w.ch.Path, w.ch.OldRev)
+			if e, ok := err.(*Error); ok && e.Err == ErrNoEnt {
+				err = nil
+			}
+		} else {
+			_, err = s.secondary.Set(w.ch.Path, w.ch.OldRev, w.ch.Body)
+		}
+		if err != nil && s.OnError != nil {
+			s.OnError(w.ch, err)
+		}
+
+		s.mu.Lock()
+		s.sending = nil
+		if err != nil {
+			s.failed++
+		} else {
+			s.sent++
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package doozer
+
+// A Snapshot reads the store as of a single revision, so that
+// several reads through it see a consistent view.
+type Snapshot struct {
+	c   Client
+	rev int64
+}
+
+// Snapshot returns a Snapshot of c at its current revision.
+func (c *Conn) Snapshot() (*Snapshot, error) {
+	rev, err := c.Rev()
+	if err != nil {
+		return nil, err
+	}
+	return c.SnapshotAt(rev), nil
+}
+
+// SnapshotAt returns a Snapshot of c at rev.
+func (c *Conn) SnapshotAt(rev int64) *Snapshot {
+	return &Snapshot{c, rev}
+}
+
+// Rev returns the revision s reads at.
+func (s *Snapshot) Rev() int64 {
+	return s.rev
+}
+
+// Get returns the body and revision of the file at path.
+func (s *Snapshot) Get(path string) ([]byte, int64, error) {
+	return s.c.Get(path, &s.rev)
+}
+
+// Getdir returns the names of the entries in dir, as Conn.Getdir
+// does.
+func (s *Snapshot) Getdir(dir string, off, lim int) ([]string, error) {
+	return s.c.Getdir(dir, s.rev, off, lim)
+}
+
+// Stat returns the length and revision of the file at path.
+func (s *Snapshot) Stat(path string) (len int, fileRev int64, err error) {
+	return s.c.Stat(path, &s.rev)
+}
+
+// Walk returns the files matching glob, as Conn.Walk does.
+func (s *Snapshot) Walk(glob string, off, lim int) ([]Event, error) {
+	return s.c.Walk(glob, s.rev, off, lim)
+}
+
+// GetTree returns the files beneath root, as GetTree does.
+func (s *Snapshot) GetTree(root string) ([]Event, error) {
+	return GetTree(s.c, root, s.rev)
+}
@@ -0,0 +1,236 @@
+package doozer
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of the traffic on a Conn since it was made.
+type Stats struct {
+	BytesIn  int64 // bytes received, including frame headers
+	BytesOut int64 // bytes sent, including frame headers
+
+	InFlight   int64 // requests awaiting a result
+	Waits      int64 // Waits awaiting an event
+	Reconnects int64 // connections replaced
+
+	// FramesIn and FramesOut count the frames received
+	// and sent by their size, without the header.
+	FramesIn, FramesOut SizeHistogram
+
+	// Verbs holds the stats for each verb used, keyed by
+	// its name, such as "GET".
+	Verbs map[string]VerbStats
+}
+
+// VerbStats describes the requests made with one verb. Latency is
+// measured from the time a request is made until its result is
+// returned, including any retries.
+type VerbStats struct {
+	Requests int64
+	Errors   int64
+
+	P50, P90, P99 time.Duration
+	Latency       Histogram
+}
+
+// A Histogram counts durations in buckets. Counts[i] is the number
+// of durations no greater than Bounds[i] and greater than the bound
+// before it; the last count is of those greater than every bound.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []int64
+	Sum    time.Duration
+}
+
+// A SizeHistogram counts sizes in bytes in buckets,
+// as a Histogram counts durations.
+type SizeHistogram struct {
+	Bounds []int64
+	Counts []int64
+	Sum    int64
+}
+
+// sizeBounds are the bucket bounds of size histograms,
+// growing by four times from 16 bytes to 4MB.
+var sizeBounds = func() []int64 {
+	b := make([]int64, 10)
+	n := int64(16)
+	for i := range b {
+		b[i] = n
+		n *= 4
+	}
+	return b
+}()
+
+func (h *SizeHistogram) observe(n int64) {
+	if h.Counts == nil {
+		h.Bounds = sizeBounds
+		h.Counts = make([]int64, len(h.Bounds)+1)
+	}
+	i := 0
+	for i < len(h.Bounds) && n > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Sum += n
+}
+
+func (h SizeHistogram) clone() SizeHistogram {
+	h.Counts = append([]int64(nil), h.Counts...)
+	return h
+}
+
+// latencyBounds are the bucket bounds of latency histograms,
+// doubling from 100µs to about 52s.
+var latencyBounds = func() []time.Duration {
+	b := make([]time.Duration, 20)
+	d := 100 * time.Microsecond
+	for i := range b {
+		b[i] = d
+		d *= 2
+	}
+	return b
+}()
+
+func (h *Histogram) observe(d time.Duration) {
+	if h.Counts == nil {
+		h.Bounds = latencyBounds
+		h.Counts = make([]int64, len(h.Bounds)+1)
+	}
+	i := 0
+	for i < len(h.Bounds) && d > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Sum += d
+}
+
+// Quantile returns an estimate of the q-quantile of the durations
+// in h, for q between 0 and 1: the upper bound of the bucket that
+// holds it. Durations in the last bucket are reported as the
+// greatest bound.
+func (h Histogram) Quantile(q float64) time.Duration {
+	var n int64
+	for _, c := range h.Counts {
+		n += c
+	}
+	if n == 0 {
+		return 0
+	}
+
+	rank := int64(q*float64(n) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank && i < len(h.Bounds) {
+			return h.Bounds[i]
+		}
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// stats is the running count kept by a Conn. Its links
+// add the bytes they move.
+type stats struct {
+	mu         sync.Mutex
+	bytesIn    int64
+	bytesOut   int64
+	inFlight   int64
+	waits      int64
+	reconnects int64
+	framesIn   SizeHistogram
+	framesOut  SizeHistogram
+	verbs      map[request_Verb]*VerbStats
+}
+
+// frame counts a frame of n bytes, plus its header.
+func (s *stats) frame(n int, out bool) {
+	s.mu.Lock()
+	if out {
+		s.bytesOut += int64(4 + n)
+		s.framesOut.observe(int64(n))
+	} else {
+		s.bytesIn += int64(4 + n)
+		s.framesIn.observe(int64(n))
+	}
+	s.mu.Unlock()
+}
+
+func (s *stats) reconnected() {
+	s.mu.Lock()
+	s.reconnects++
+	s.mu.Unlock()
+}
+
+// start counts a request with verb v as in flight.
+func (s *stats) start(v request_Verb) {
+	s.mu.Lock()
+	s.inFlight++
+	if v == request_WAIT {
+		s.waits++
+	}
+	s.mu.Unlock()
+}
+
+// observe counts the end of a request begun with start.
+func (s *stats) observe(v request_Verb, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if v == request_WAIT {
+		s.waits--
+	}
+	if s.verbs == nil {
+		s.verbs = make(map[request_Verb]*VerbStats)
+	}
+	vs := s.verbs[v]
+	if vs == nil {
+		vs = new(VerbStats)
+		s.verbs[v] = vs
+	}
+	vs.Requests++
+	if err != nil {
+		vs.Errors++
+	}
+	vs.Latency.observe(d)
+}
+
+// Stats returns a snapshot of the traffic on c.
+func (c *Conn) Stats() Stats {
+	s := &c.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := Stats{
+		BytesIn:    s.bytesIn,
+		BytesOut:   s.bytesOut,
+		InFlight:   s.inFlight,
+		Waits:      s.waits,
+		Reconnects: s.reconnects,
+		FramesIn:   s.framesIn.clone(),
+		FramesOut:  s.framesOut.clone(),
+		Verbs:      make(map[string]VerbStats, len(s.verbs)),
+	}
+	for v, vs := range s.verbs {
+		cp := *vs
+		cp.Latency.Counts = append([]int64(nil), vs.Latency.Counts...)
+		cp.P50 = cp.Latency.Quantile(0.5)
+		cp.P90 = cp.Latency.Quantile(0.9)
+		cp.P99 = cp.Latency.Quantile(0.99)
+		st.Verbs[request_Verb_name[int32(v)]] = cp
+	}
+	return st
+}
+
+// Publish exports c's Stats with the expvar package under name.
+// Like expvar.Publish, it panics if name is already in use.
+func (c *Conn) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Stats()
+	}))
+}
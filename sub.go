@@ -0,0 +1,82 @@
+package doozer
+
+import (
+	"strings"
+)
+
+// Sub returns a Client for the subtree of c at prefix. Paths and
+// globs given to it are taken to be relative to prefix, so "/" names
+// prefix itself, and paths it returns, as in Events, are made
+// relative in the same way. Closing it does not close c.
+func (c *Conn) Sub(prefix string) Client {
+	return &subClient{c, strings.TrimRight(prefix, "/")}
+}
+
+type subClient struct {
+	c      Client
+	prefix string
+}
+
+func (s *subClient) full(path string) string {
+	if path == "/" {
+		if s.prefix == "" {
+			return "/"
+		}
+		return s.prefix
+	}
+	return s.prefix + path
+}
+
+func (s *subClient) rebase(path string) string {
+	path = strings.TrimPrefix(path, s.prefix)
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func (s *subClient) Access(token string) error {
+	return s.c.Access(token)
+}
+
+func (s *subClient) Get(file string, rev *int64) ([]byte, int64, error) {
+	return s.c.Get(s.full(file), rev)
+}
+
+func (s *subClient) Set(file string, oldRev int64, body []byte) (int64, error) {
+	return s.c.Set(s.full(file), oldRev, body)
+}
+
+func (s *subClient) Del(file string, rev int64) error {
+	return s.c.Del(s.full(file), rev)
+}
+
+func (s *subClient) Getdir(dir string, rev int64, off, lim int) ([]string, error) {
+	return s.c.Getdir(s.full(dir), rev, off, lim)
+}
+
+func (s *subClient) Stat(path string, storeRev *int64) (int, int64, error) {
+	return s.c.Stat(s.full(path), storeRev)
+}
+
+func (s *subClient) Walk(glob string, rev int64, off, lim int) ([]Event, error) {
+	evs, err := s.c.Walk(s.full(glob), rev, off, lim)
+	for i := range evs {
+		evs[i].Path = s.rebase(evs[i].Path)
+	}
+	return evs, err
+}
+
+func (s *subClient) Wait(glob string, rev int64) (Event, error) {
+	ev, err := s.c.Wait(s.full(glob), rev)
+	if err == nil {
+		ev.Path = s.rebase(ev.Path)
+	}
+	return ev, err
+}
+
+func (s *subClient) Rev() (int64, error) {
+	return s.c.Rev()
+}
+
+func (s *subClient) Close() {}
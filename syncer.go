@@ -0,0 +1,230 @@
+package doozer
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// A ConflictPolicy says what a Syncer does when a file in the
+// destination has been modified by someone other than the Syncer.
+type ConflictPolicy int
+
+const (
+	// SyncOverwrite writes the source's file regardless.
+	SyncOverwrite ConflictPolicy = iota
+
+	// SyncSkip leaves the destination's file as it is, and stops
+	// syncing it.
+	SyncSkip
+
+	// SyncFail stops the Syncer with a *PatchError.
+	SyncFail
+)
+
+// A Syncer copies the files matching a glob from one store to
+// another, such as from one cluster to another during a migration,
+// and then keeps the copy up to date as the source changes.
+//
+// A file in the destination conflicts if it differs from the
+// source's when the Syncer starts, or if it is modified other than
+// by the Syncer afterwards. Conflict says what to do then.
+//
+// If the source discards history the Syncer has yet to copy, it
+// backfills as Watch does, so deletions in the lost history are not
+// copied.
+type Syncer struct {
+	Conflict ConflictPolicy
+
+	// OnConflict, if set, is called with the path of each file
+	// skipped under SyncSkip.
+	OnConflict func(path string)
+
+	src  *Conn
+	dst  Client
+	glob string
+
+	mu   sync.Mutex
+	rev  int64            // last revision of the source copied
+	revs map[string]int64 // revision of each file as the Syncer wrote it
+}
+
+// NewSyncer returns a Syncer of the files matching glob from src to
+// dst.
+func NewSyncer(src *Conn, dst Client, glob string) *Syncer {
+	return &Syncer{src: src, dst: dst, glob: glob}
+}
+
+// Run copies the files, then copies each change to them until ctx
+// is done or an error occurs. It returns ctx's error or the error
+// that stopped it.
+func (s *Syncer) Run(ctx context.Context) error {
+	rev, err := s.copy()
+	if err != nil {
+		return err
+	}
+
+	evs, cancel, err := s.src.Watch(s.glob, rev+1)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	for {
+		select {
+		case ev, ok := <-evs:
+			if !ok {
+				return cancel()
+			}
+			if ev.IsSet() {
+				err = s.set(ev.Path, ev.Body)
+			} else {
+				err = s.del(ev.Path)
+			}
+			if err != nil {
+				return err
+			}
+			s.mu.Lock()
+			s.rev = ev.Rev
+			s.mu.Unlock()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Rev returns the last revision of the source copied.
+func (s *Syncer) Rev() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rev
+}
+
+// copy makes the destination match the source at its current
+// revision, and returns that revision.
+func (s *Syncer) copy() (int64, error) {
+	rev, err := s.src.Rev()
+	if err != nil {
+		return 0, err
+	}
+	evs, err := s.src.walkAll(s.glob, rev)
+	if err != nil {
+		return 0, err
+	}
+	drev, err := s.dst.Rev()
+	if err != nil {
+		return 0, err
+	}
+	old, err := s.dst.Walk(s.glob, drev, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+
+	s.revs = make(map[string]int64)
+	have := make(map[string]Event, len(old))
+	for _, ev := range old {
+		have[ev.Path] = ev
+	}
+	for _, ev := range evs {
+		d, ok := have[ev.Path]
+		delete(have, ev.Path)
+		switch {
+		case ok && bytes.Equal(d.Body, ev.Body):
+			s.revs[ev.Path] = d.Rev
+		case ok:
+			s.revs[ev.Path] = d.Rev
+			if err := s.conflict(ev.Path, ev.Body, d.Rev); err != nil {
+				return 0, err
+			}
+		default:
+			if err := s.set(ev.Path, ev.Body); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for path, d := range have {
+		s.revs[path] = d.Rev
+		if err := s.conflict(path, nil, d.Rev); err != nil {
+			return 0, err
+		}
+	}
+
+	s.mu.Lock()
+	s.rev = rev
+	s.mu.Unlock()
+	return rev, nil
+}
+
+// conflict handles a destination file, at revision drev, that should
+// have body, or be deleted if body is nil, but differs.
+func (s *Syncer) conflict(path string, body []byte, drev int64) error {
+	switch s.Conflict {
+	case SyncOverwrite:
+		if body == nil {
+			return s.del(path)
+		}
+		return s.set(path, body)
+	case SyncSkip:
+		s.skip(path)
+		return nil
+	}
+	return &PatchError{path, ErrOldRev}
+}
+
+func (s *Syncer) skip(path string) {
+	s.revs[path] = nop
+	if s.OnConflict != nil {
+		s.OnConflict(path)
+	}
+}
+
+// set writes body to path in the destination, on the condition that
+// it is as the Syncer last wrote it.
+func (s *Syncer) set(path string, body []byte) error {
+	old, ok := s.revs[path]
+	switch {
+	case old == nop:
+		return nil
+	case s.Conflict == SyncOverwrite:
+		old = clobber
+	case !ok:
+		old = missing
+	}
+	rev, err := s.dst.Set(path, old, body)
+	if e, ok := err.(*Error); ok && e.Err == ErrOldRev && s.Conflict == SyncSkip {
+		s.skip(path)
+		return nil
+	}
+	if err != nil {
+		return &PatchError{path, err}
+	}
+	s.revs[path] = rev
+	return nil
+}
+
+// del deletes path in the destination, on the condition that it is
+// as the Syncer last wrote it.
+func (s *Syncer) del(path string) error {
+	old, ok := s.revs[path]
+	switch {
+	case old == nop:
+		return nil
+	case s.Conflict == SyncOverwrite:
+		old = clobber
+	case !ok:
+		return nil
+	}
+	err := s.dst.Del(path, old)
+	if e, ok := err.(*Error); ok && e.Err == ErrNoEnt {
+		err = nil
+	}
+	if e, ok := err.(*Error); ok && e.Err == ErrOldRev && s.Conflict == SyncSkip {
+		s.skip(path)
+		return nil
+	}
+	if err != nil {
+		return &PatchError{path, err}
+	}
+	delete(s.revs, path)
+	return nil
+}
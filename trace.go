@@ -0,0 +1,50 @@
+package doozer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+// trace writes a description of a frame to l.cfg.Trace, if set:
+// a header line giving the time, the direction ("->" for a request,
+// "<-" for a response), the verb and tag of a request, and the size
+// of the frame, followed by the
+// decoded message, in protobuf text format, or by a hex dump of the
+// frame if it could not be decoded.
+func (l *link) trace(dir string, buf []byte) {
+	w := l.cfg.Trace
+	if w == nil {
+		return
+	}
+
+	var msg proto.Message
+	if dir == "->" {
+		var req request
+		if proto.Unmarshal(buf, &req) == nil && req.Verb != nil && req.Tag != nil {
+			msg = &req
+		}
+	} else {
+		var resp response
+		if proto.Unmarshal(buf, &resp) == nil {
+			msg = &resp
+		}
+	}
+
+	l.tmu.Lock()
+	defer l.tmu.Unlock()
+
+	what := ""
+	if req, ok := msg.(*request); ok {
+		what = fmt.Sprintf(" %v tag=%d", request_Verb_name[int32(*req.Verb)], *req.Tag)
+	}
+	fmt.Fprintf(w, "%s %s %s%s %d bytes\n",
+		time.Now().Format("15:04:05.000000"), l.conn.RemoteAddr(), dir, what, len(buf))
+	if msg != nil {
+		fmt.Fprint(w, prototext.MarshalOptions{Multiline: true}.Format(msg))
+	} else {
+		fmt.Fprint(w, hex.Dump(buf))
+	}
+}
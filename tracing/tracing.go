@@ -0,0 +1,85 @@
+// Package tracing records a span for each doozer request with
+// OpenTelemetry.
+//
+// For example:
+//
+//	c.Use(tracing.Interceptor(tp))
+//
+// A span is the child of a span in the request's context. Only the
+// methods that take a context, such as GetContext, SetContext, and
+// WaitContext, carry the caller's; the others use
+// context.Background, so their spans start new traces.
+package tracing
+
+import (
+	"context"
+	"github.com/dcjones/doozer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/dcjones/doozer/tracing"
+
+// Interceptor returns an Interceptor that starts a client span from
+// tp for each request, as a child of any span in the request's
+// context. The span is named for the verb, such as "doozer.GET",
+// and records the path, revision, and the error code of a failure.
+// A nil tp means no spans are recorded.
+func Interceptor(tp trace.TracerProvider) doozer.Interceptor {
+	if tp == nil {
+		return func(ctx context.Context, req *doozer.Request, next doozer.Invoker) (*doozer.Response, error) {
+			return next(ctx, req)
+		}
+	}
+
+	tr := tp.Tracer(instrumentation)
+	return func(ctx context.Context, req *doozer.Request, next doozer.Invoker) (*doozer.Response, error) {
+		attrs := []attribute.KeyValue{
+			attribute.String("rpc.system", "doozer"),
+			attribute.String("rpc.method", req.Verb),
+		}
+		if req.Path != "" {
+			attrs = append(attrs, attribute.String("doozer.path", req.Path))
+		}
+		if req.Rev != nil {
+			attrs = append(attrs, attribute.Int64("doozer.rev", *req.Rev))
+		}
+		ctx, span := tr.Start(ctx, "doozer."+req.Verb,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...))
+		defer span.End()
+
+		resp, err := next(ctx, req)
+		if err != nil {
+			span.SetAttributes(attribute.String("doozer.error", errorCode(err)))
+			span.SetStatus(codes.Error, err.Error())
+			return resp, err
+		}
+		if resp != nil {
+			span.SetAttributes(attribute.Int64("doozer.response.rev", resp.Rev))
+		}
+		return resp, nil
+	}
+}
+
+// errorCode returns the code of an error reported by the server,
+// such as "REV_MISMATCH", or names a failure of the client, such
+// as "CONN" for a failed connection.
+func errorCode(err error) string {
+	if e, ok := err.(*doozer.Error); ok {
+		if e.Err == doozer.ErrAccess {
+			return "ACCESS"
+		}
+		return e.Err.Error()
+	}
+	switch err {
+	case context.Canceled, context.DeadlineExceeded:
+		return "CANCELED"
+	case doozer.ErrClosed:
+		return "CLOSED"
+	case doozer.ErrUnavailable:
+		return "UNAVAILABLE"
+	}
+	return "CONN"
+}
@@ -0,0 +1,116 @@
+package doozer
+
+// A Txn reads files as of a single revision and buffers writes to
+// them, to be made together by Commit, each on the condition that
+// nothing the Txn read or wrote has been modified since.
+//
+// The server has no transactions spanning several files, so Commit
+// is not atomic: it checks the files read before making the writes,
+// and a write can still fail partway through, leaving those before
+// it made. Commit then returns a *PatchError for the file in
+// conflict, and the caller may start over with a new Txn.
+type Txn struct {
+	c      Client
+	rev    int64
+	reads  map[string]int64 // revision of each file read
+	order  []string
+	writes map[string]Change
+}
+
+// NewTxn returns a Txn that reads from c at its current revision.
+func NewTxn(c Client) (*Txn, error) {
+	rev, err := c.Rev()
+	if err != nil {
+		return nil, err
+	}
+	return &Txn{
+		c:      c,
+		rev:    rev,
+		reads:  make(map[string]int64),
+		writes: make(map[string]Change),
+	}, nil
+}
+
+// Rev returns the revision t reads at.
+func (t *Txn) Rev() int64 {
+	return t.rev
+}
+
+// Get returns the body of the file at path, including any write
+// buffered in t. A missing file has a nil body.
+func (t *Txn) Get(path string) ([]byte, error) {
+	if ch, ok := t.writes[path]; ok {
+		return ch.Body, nil
+	}
+	body, frev, err := t.c.Get(path, &t.rev)
+	if err != nil {
+		return nil, err
+	}
+	t.reads[path] = frev
+	return body, nil
+}
+
+// Set buffers a write of body to the file at path.
+func (t *Txn) Set(path string, body []byte) {
+	t.write(Change{Path: path, Body: body})
+}
+
+// Del buffers the deletion of the file at path.
+func (t *Txn) Del(path string) {
+	t.write(Change{Path: path, Del: true})
+}
+
+func (t *Txn) write(ch Change) {
+	if _, ok := t.writes[ch.Path]; !ok {
+		t.order = append(t.order, ch.Path)
+	}
+	t.writes[ch.Path] = ch
+}
+
+// Commit checks that no file t read has been modified since t's
+// revision, and then makes the buffered writes in the order they
+// were first made, each on the condition that the file has not been
+// modified since t's revision.
+func (t *Txn) Commit() error {
+	for path, rev := range t.reads {
+		if _, ok := t.writes[path]; ok {
+			continue
+		}
+		_, frev, err := t.c.Stat(path, nil)
+		if err != nil {
+			return &PatchError{path, err}
+		}
+		if frev != rev {
+			return &PatchError{path, ErrOldRev}
+		}
+	}
+
+	var changes []Change
+	for _, path := range t.order {
+		ch := t.writes[path]
+		rev, ok := t.reads[path]
+		if !ok {
+			_, frev, err := t.c.Stat(path, &t.rev)
+			if err != nil {
+				return &PatchError{path, err}
+			}
+			rev = frev
+		}
+		if ch.Del && rev == missing {
+			// There is nothing to delete, so long as it stays so.
+			_, frev, err := t.c.Stat(path, nil)
+			if err == nil && frev != missing {
+				err = ErrOldRev
+			}
+			if err != nil {
+				return &PatchError{path, err}
+			}
+			continue
+		}
+		ch.OldRev = rev
+		changes = append(changes, ch)
+	}
+
+	_, err := Apply(t.c, changes)
+	return err
+}
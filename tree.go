@@ -0,0 +1,141 @@
+package doozer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GetTree returns every file beneath root, at revision rev, in
+// lexicographical order by path, with its body and revision.
+// If c is a *Conn, the files are requested several at a time.
+func GetTree(c Client, root string, rev int64) ([]Event, error) {
+	return walkAll(c, treeGlob(root), rev)
+}
+
+// DelTree deletes every file beneath root, as of revision rev, the
+// most deeply nested first, each on the condition that it has not
+// been modified since rev. It stops at the first file that cannot
+// be deleted, returning the files deleted so far and a *PatchError.
+func DelTree(c Client, root string, rev int64) (deleted []Event, err error) {
+	evs, err := GetTree(c, root, rev)
+	if err != nil {
+		return nil, err
+	}
+	sort.Stable(byDepth(evs))
+
+	for _, ev := range evs {
+		err = c.Del(ev.Path, ev.Rev)
+		if err != nil {
+			return deleted, &PatchError{ev.Path, err}
+		}
+		deleted = append(deleted, ev)
+	}
+	return deleted, nil
+}
+
+// CopyTree writes a copy of every file beneath src, as of revision
+// rev, to the same path beneath dst, overwriting any file already
+// there; other files beneath dst are left alone. It returns the
+// number of files written. If a file beneath src is modified after
+// rev, before the copy is complete, the copy may be stale, and
+// CopyTree returns a *PatchError for that file with ErrOldRev.
+func CopyTree(c Client, src, dst string, rev int64) (n int, err error) {
+	evs, err := GetTree(c, src, rev)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := make([]Change, len(evs))
+	for i, ev := range evs {
+		changes[i] = Change{
+			Path:   strings.TrimSuffix(dst, "/") + strings.TrimPrefix(ev.Path, strings.TrimSuffix(src, "/")),
+			OldRev: clobber,
+			Body:   ev.Body,
+		}
+	}
+	n, err = Apply(c, changes)
+	if err != nil {
+		return n, err
+	}
+
+	cur, err := c.Rev()
+	if err != nil {
+		return n, err
+	}
+	diff, err := Diff(c, treeGlob(src), rev, cur)
+	if err != nil {
+		return n, err
+	}
+	if len(diff) > 0 {
+		return n, &PatchError{diff[0].Path, ErrOldRev}
+	}
+	return n, nil
+}
+
+// MoveTree copies every file beneath src to dst, as CopyTree does,
+// and then deletes them from src, as DelTree does.
+func MoveTree(c Client, src, dst string, rev int64) error {
+	_, err := CopyTree(c, src, dst, rev)
+	if err != nil {
+		return err
+	}
+	_, err = DelTree(c, src, rev)
+	return err
+}
+
+// byDepth orders files the most deeply nested first.
+type byDepth []Event
+
+func (a byDepth) Len() int { return len(a) }
+func (a byDepth) Less(i, j int) bool {
+	return strings.Count(a[i].Path, "/") > strings.Count(a[j].Path, "/")
+}
+func (a byDepth) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+// treeGlob returns the glob matching every file beneath root.
+func treeGlob(root string) string {
+	if root == "/" {
+		return "/**"
+	}
+	return root + "/**"
+}
+
+// walkAll reads every file matching glob, at revision rev. If c is a
+// *Conn, the files are requested several at a time.
+func walkAll(c Client, glob string, rev int64) ([]Event, error) {
+	if c, ok := c.(*Conn); ok {
+		return c.walkAll(glob, rev)
+	}
+	return c.Walk(glob, rev, 0, -1)
+}
+
+// walkAll reads every file matching glob, at revision rev, sending
+// up to c's parallelism of requests at once.
+func (c *Conn) walkAll(glob string, rev int64) (evs []Event, err error) {
+	n := c.cfg.parallelism()
+	batch := make([]Event, n)
+	errs := make([]error, n)
+	for off := 0; ; off += n {
+		var wg sync.WaitGroup
+		for i := range batch {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				batch[i], errs[i] = c.walkAt(context.Background(), glob, rev, off+i)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range batch {
+			if err, ok := errs[i].(*Error); ok && err.Err == ErrRange {
+				return evs, nil
+			}
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			evs = append(evs, batch[i])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build go1.18
+
+package doozer
+
+import (
+	"reflect"
+)
+
+// Get reads the file at path, as of rev, as Get does, and returns
+// its body decoded as a T with the connection's codec. If T is a
+// pointer type, such as a protocol buffer message, a new value is
+// allocated to decode into. If the file is missing, Get returns the
+// zero T and a revision of 0. Use GetValue to decode with another
+// codec.
+func Get[T any](c Client, path string, rev *int64) (T, int64, error) {
+	var v T
+	t := reflect.TypeOf(&v).Elem()
+	if t.Kind() != reflect.Ptr {
+		frev, err := GetValue(c, nil, path, rev, &v)
+		return v, frev, err
+	}
+
+	p := reflect.New(t.Elem()).Interface()
+	frev, err := GetValue(c, nil, path, rev, p)
+	if err != nil || frev == missing {
+		return v, frev, err
+	}
+	return p.(T), frev, nil
+}
+
+// Set writes v, encoded with the connection's codec, to the file at
+// path, as Set does.
+func Set[T any](c Client, path string, oldRev int64, v T) (newRev int64, err error) {
+	return SetValue(c, nil, path, oldRev, v)
+}
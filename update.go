@@ -0,0 +1,68 @@
+package doozer
+
+import (
+	"time"
+)
+
+// UpdateAttempts is the most times Update or Replace tries to write
+// a file.
+const UpdateAttempts = 10
+
+// An UpdateFunc computes the new body of a file from its old one,
+// which is nil if the file is missing.
+type UpdateFunc func(old []byte) (new []byte, err error)
+
+// Update reads the file at path, passes its body to fn, and writes
+// the result, on the condition that the file has not been modified
+// since it was read. If it has, Update starts again, up to
+// UpdateAttempts times in all, backing off a little more each time.
+// It returns the file's new revision, or the error from fn or from
+// the last attempt.
+func Update(c Client, path string, fn UpdateFunc) (rev int64, err error) {
+	backoff := ExponentialBackoff(time.Millisecond, 100*time.Millisecond)
+	for n := 1; ; n++ {
+		body, frev, err := c.Get(path, nil)
+		if err != nil {
+			return 0, err
+		}
+		body, err = fn(body)
+		if err != nil {
+			return 0, err
+		}
+		rev, err = c.Set(path, frev, body)
+		if e, ok := err.(*Error); ok && e.Err == ErrOldRev && n < UpdateAttempts {
+			time.Sleep(backoff(n))
+			continue
+		}
+		return rev, err
+	}
+}
+
+// Create writes body to a new file at path. It fails with ErrExists
+// if the file already exists.
+func Create(c Client, path string, body []byte) (rev int64, err error) {
+	rev, err = c.Set(path, missing, body)
+	if e, ok := err.(*Error); ok && e.Err == ErrOldRev {
+		return 0, &Error{ErrExists, path}
+	}
+	return rev, err
+}
+
+// Replace writes body to the existing file at path. It fails with
+// ErrNoEnt if the file does not exist.
+func Replace(c Client, path string, body []byte) (rev int64, err error) {
+	for n := 1; ; n++ {
+		_, frev, err := c.Stat(path, nil)
+		if err != nil {
+			return 0, err
+		}
+		if frev == missing {
+			return 0, &Error{ErrNoEnt, path}
+		}
+		rev, err = c.Set(path, frev, body)
+		if e, ok := err.(*Error); ok && e.Err == ErrOldRev && n < UpdateAttempts {
+			continue
+		}
+		return rev, err
+	}
+}
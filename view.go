@@ -0,0 +1,232 @@
+package doozer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ViewFunc computes a value from the store. Every read it makes
+// through r is recorded as a source of the value.
+type ViewFunc func(r *ViewReader) (interface{}, error)
+
+// A View caches the result of a ViewFunc. The value is recomputed
+// on the next call to Value after any of its sources changes or
+// after the TTL elapses. A value read from a path that its Client's
+// contracts require to be Linearizable is never cached.
+type View struct {
+	c      Client
+	f      ViewFunc
+	ttl    time.Duration
+	ctx    context.Context // done once v is closed
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	val      interface{}
+	rev      int64
+	sources  map[string]int64
+	globs    map[string]string // glob -> directory listed, if any
+	watching map[string]bool
+	at       time.Time
+	valid    bool
+	closed   bool
+}
+
+// NewView returns a View of f over the store at c. A ttl of zero
+// means the value is only recomputed when a source changes.
+func NewView(c Client, ttl time.Duration, f ViewFunc) *View {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &View{
+		c:        c,
+		f:        f,
+		ttl:      ttl,
+		ctx:      ctx,
+		cancel:   cancel,
+		watching: make(map[string]bool),
+	}
+}
+
+// Value returns the cached value and the revisions of the files it
+// was computed from, recomputing it first if it is out of date.
+func (v *View) Value() (val interface{}, sources map[string]int64, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.closed {
+		return nil, nil, ErrClosed
+	}
+
+	if !v.valid || v.ttl > 0 && time.Since(v.at) >= v.ttl {
+		err = v.compute()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sources = make(map[string]int64, len(v.sources))
+	for path, rev := range v.sources {
+		sources[path] = rev
+	}
+	return v.val, sources, nil
+}
+
+// Rev returns the store revision the cached value was computed at.
+func (v *View) Rev() int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.rev
+}
+
+// Invalidate forces the value to be recomputed on the next call to Value.
+func (v *View) Invalidate() {
+	v.mu.Lock()
+	v.valid = false
+	v.mu.Unlock()
+}
+
+// Close releases the cached value, and stops waiting for changes to
+// its sources.
+func (v *View) Close() {
+	v.cancel()
+	v.mu.Lock()
+	v.closed = true
+	v.valid = false
+	v.val = nil
+	v.globs = nil
+	v.mu.Unlock()
+}
+
+// compute must be called with v.mu held.
+func (v *View) compute() error {
+	rev, err := v.c.Rev()
+	if err != nil {
+		return err
+	}
+
+	r := &ViewReader{
+		c:       v.c,
+		rev:     rev,
+		sources: make(map[string]int64),
+		globs:   make(map[string]string),
+	}
+	val, err := v.f(r)
+	if err != nil {
+		return err
+	}
+
+	v.val = val
+	v.rev = rev
+	v.sources = r.sources
+	v.globs = r.globs
+	v.at = time.Now()
+	v.valid = !r.linear
+
+	for glob, dir := range v.globs {
+		if !v.watching[glob] {
+			v.watching[glob] = true
+			go v.watch(glob, dir, rev+1)
+		}
+	}
+	return nil
+}
+
+// watch invalidates the value when a file matching glob changes.
+// If dir is set, glob covers the tree beneath it, and only changes
+// that add or remove an entry of dir count.
+func (v *View) watch(glob, dir string, rev int64) {
+	for {
+		ev, err := waitContext(v.ctx, v.c, glob, rev)
+		changed := err == nil && (dir == "" || entryChanged(v.c, dir, ev))
+
+		v.mu.Lock()
+		_, ok := v.globs[glob]
+		if err != nil || v.closed || !ok {
+			if err != nil {
+				v.valid = false
+			}
+			delete(v.watching, glob)
+			v.mu.Unlock()
+			return
+		}
+		if changed && ev.Rev > v.rev {
+			v.valid = false
+		}
+		v.mu.Unlock()
+
+		rev = ev.Rev + 1
+	}
+}
+
+// A ViewReader reads the store at a fixed revision on behalf of a
+// ViewFunc, recording what it reads.
+type ViewReader struct {
+	c       Client
+	rev     int64
+	sources map[string]int64
+	globs   map[string]string
+	linear  bool // whether a source must be read linearizably
+}
+
+// Rev returns the store revision r reads at.
+func (r *ViewReader) Rev() int64 {
+	return r.rev
+}
+
+// Get returns the body of the file at path. A missing file is
+// recorded too, so that creating it invalidates the view.
+func (r *ViewReader) Get(path string) ([]byte, error) {
+	body, rev, err := r.c.Get(path, &r.rev)
+	if err != nil {
+		return nil, err
+	}
+	r.sources[path] = rev
+	r.globs[path] = ""
+	r.note(path)
+	return body, nil
+}
+
+// Getdir returns the names of the entries in dir. Adding or removing
+// an entry invalidates the view.
+func (r *ViewReader) Getdir(dir string) ([]string, error) {
+	names, err := r.c.Getdir(dir, r.rev, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	// A file created deep beneath dir may add an entry,
+	// so watch the whole tree.
+	r.globs[treeGlob(dir)] = dir
+	r.note(dir)
+	return names, nil
+}
+
+// entryChanged reports whether ev, a change beneath dir, added or
+// removed an entry of dir. If that can't be told, it reports true.
+func entryChanged(c Client, dir string, ev Event) bool {
+	prefix := dir + "/"
+	if dir == "/" {
+		prefix = "/"
+	}
+	name := strings.TrimPrefix(ev.Path, prefix)
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	entry := prefix + name
+
+	before := ev.Rev - 1
+	_, was, err := c.Stat(entry, &before)
+	if err != nil {
+		return true
+	}
+	_, is, err := c.Stat(entry, &ev.Rev)
+	if err != nil {
+		return true
+	}
+	return (was == missing) != (is == missing)
+}
+
+func (r *ViewReader) note(path string) {
+	if consistencyOf(r.c, path) == Linearizable {
+		r.linear = true
+	}
+}
@@ -1,13 +1,39 @@
 package doozer
 
 import (
+	"context"
+	"errors"
 	"path/filepath"
 )
 
+// SkipAll, returned by an EventFunc, stops WalkGlob without error.
+var SkipAll = errors.New("skip everything and stop the walk")
+
+// An EventFunc is called by WalkGlob with each file it visits.
+type EventFunc func(ev Event) error
+
+// WalkGlob calls fn with each file matching glob, at revision rev,
+// in lexicographical order, reading each file only once fn has
+// returned for the one before. If fn returns an error, WalkGlob
+// stops and returns it, unless it is SkipAll.
+func (c *Conn) WalkGlob(glob string, rev int64, fn EventFunc) error {
+	it := c.WalkIter(context.Background(), glob, rev)
+	for it.Next() {
+		err := fn(it.Event())
+		if err == SkipAll {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
 // Walk walks the file tree in revision rev, rooted at root,
 // analogously to Walk in package path/filepath.
-func Walk(c *Conn, rev int64, root string, v WalkFunc) error {
-	f, err := c.Statinfo(rev, root)
+func Walk(c Client, rev int64, root string, v WalkFunc) error {
+	f, err := Statinfo(c, rev, root)
 	if err != nil {
 		v(root, f, err)
 		return err
@@ -15,7 +41,7 @@ func Walk(c *Conn, rev int64, root string, v WalkFunc) error {
 	return walk(c, rev, root, f, v)
 }
 
-func walk(c *Conn, r int64, path string, f *FileInfo, v WalkFunc) (err error) {
+func walk(c Client, r int64, path string, f *FileInfo, v WalkFunc) (err error) {
 	verr := v(path, f, nil)
 	if !f.IsDir || verr == filepath.SkipDir {
 		return
@@ -24,7 +50,7 @@ func walk(c *Conn, r int64, path string, f *FileInfo, v WalkFunc) (err error) {
 		return err
 	}
 
-	list, err := c.Getdirinfo(path, r, 0, -1)
+	list, err := Getdirinfo(c, path, r, 0, -1)
 	if err != nil {
 		verr = v(path, f, err)
 		if verr != nil {
@@ -0,0 +1,204 @@
+package doozer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A CancelFunc stops a Watch. It returns the error that ended the
+// stream of events, if it had already ended, or nil.
+type CancelFunc func() error
+
+// Watch delivers, in order, every change on or after fromRev to a
+// file matching glob. It waits for each change in turn, and if the
+// connection fails, reconnects and carries on from the revision after
+// the last one delivered, so no change is missed or repeated.
+//
+// If the server has meanwhile discarded that revision, as it reports
+// with ErrTooLate, Watch backfills instead: it walks the files
+// matching glob at the current revision, delivers as sets, in order
+// of revision, those changed since the last one delivered, and then
+// carries on from the current revision. Each file's latest body is
+// thus delivered at least once, and revisions still only increase,
+// but intermediate sets and any deletions in the lost history are
+// not reported.
+//
+// The channel is closed when cancel is called, when c is closed, or
+// when the server fails the watch. Cancel then reports why. Use
+// WatchGaps to be told of lost history instead of having it
+// backfilled.
+//
+// For example:
+//
+//	evs, cancel, err := c.Watch("/config/**", rev)
+//	if err != nil {
+//		return err
+//	}
+//	for ev := range evs {
+//		...
+//	}
+//	return cancel()
+func (c *Conn) Watch(glob string, fromRev int64) (<-chan Event, CancelFunc, error) {
+	return c.watch(glob, fromRev, false)
+}
+
+// WatchGaps is like Watch, but instead of backfilling lost history,
+// it closes the channel, and cancel returns a *GapError giving the
+// revisions that were lost. Every change delivered before then is
+// delivered in order, with none missed, so the caller knows exactly
+// which revisions it has yet to account for.
+func (c *Conn) WatchGaps(glob string, fromRev int64) (<-chan Event, CancelFunc, error) {
+	return c.watch(glob, fromRev, true)
+}
+
+func (c *Conn) watch(glob string, fromRev int64, gaps bool) (<-chan Event, CancelFunc, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, nil, ErrClosed
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	w := &watch{c: c, glob: glob, rev: fromRev, gaps: gaps, ch: ch, stop: stop}
+	go w.run(ctx)
+
+	return ch, w.cancel, nil
+}
+
+type watch struct {
+	c    *Conn
+	glob string
+	rev  int64
+	gaps bool
+	ch   chan Event
+	stop context.CancelFunc
+
+	mu   sync.Mutex
+	err  error
+	done bool
+}
+
+func (w *watch) cancel() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stop()
+	if !w.done {
+		return nil
+	}
+	return w.err
+}
+
+func (w *watch) run(ctx context.Context) {
+	err := w.loop(ctx)
+	w.mu.Lock()
+	if ctx.Err() == nil {
+		w.err = err
+	}
+	w.done = true
+	w.mu.Unlock()
+	close(w.ch)
+}
+
+func (w *watch) loop(ctx context.Context) error {
+	backoff := ExponentialBackoff(10*time.Millisecond, time.Second)
+	failures := 0
+	for {
+		w.c.mu.Lock()
+		l := w.c.l
+		w.c.mu.Unlock()
+
+		ev, err := w.c.waitOnce(ctx, w.glob, w.rev)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if isTooLate(err) && w.gaps {
+			return w.gap(ctx)
+		}
+		if isTooLate(err) {
+			err = w.backfill(ctx)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+		}
+		if err != nil {
+			if !IsTransient(err) {
+				return err
+			}
+			failures++
+			timer := time.NewTimer(backoff(failures))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			}
+			if err := w.c.repair(l); err == ErrClosed {
+				return err
+			}
+			continue
+		}
+		failures = 0
+
+		select {
+		case w.ch <- ev:
+		case <-ctx.Done():
+			return nil
+		}
+		w.rev = ev.Rev + 1
+	}
+}
+
+// gap returns a *GapError for the history from w.rev up to the
+// current revision.
+func (w *watch) gap(ctx context.Context) error {
+	rev, err := w.c.rev(ctx)
+	if err != nil {
+		return err
+	}
+	return &GapError{Glob: w.glob, From: w.rev, To: rev}
+}
+
+// backfill delivers the files matching w.glob that have changed since
+// the last delivered revision, as of the current revision, and moves
+// w on past it.
+func (w *watch) backfill(ctx context.Context) error {
+	rev, err := w.c.rev(ctx)
+	if err != nil {
+		return err
+	}
+	var evs []Event
+	for off := 0; ; off++ {
+		ev, err := w.c.walkAt(ctx, w.glob, rev, off)
+		if err, ok := err.(*Error); ok && err.Err == ErrRange {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		evs = append(evs, ev)
+	}
+	sort.Sort(byRev(evs))
+
+	for _, ev := range evs {
+		if ev.Rev < w.rev {
+			continue
+		}
+		select {
+		case w.ch <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	w.rev = rev + 1
+	return nil
+}
+
+type byRev []Event
+
+func (a byRev) Len() int           { return len(a) }
+func (a byRev) Less(i, j int) bool { return a[i].Rev < a[j].Rev }
+func (a byRev) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }